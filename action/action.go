@@ -0,0 +1,163 @@
+package action
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Runner is the environment that the GitHub Actions runner gives to a step
+// through environment variables.
+type Runner struct {
+	// EventName is the name of the event that triggered the workflow, e.g.
+	// pull_request or push
+	EventName string
+
+	// EventPath is the path to the JSON payload of the event
+	EventPath string
+
+	// OutputPath is the path to the file that step outputs are written to
+	OutputPath string
+
+	// SummaryPath is the path to the file that the markdown of the job
+	// summary is written to
+	SummaryPath string
+}
+
+// FromEnv returns the runner described by the environment variables set by
+// GitHub Actions.
+func FromEnv() Runner {
+	return Runner{
+		EventName:   os.Getenv("GITHUB_EVENT_NAME"),
+		EventPath:   os.Getenv("GITHUB_EVENT_PATH"),
+		OutputPath:  os.Getenv("GITHUB_OUTPUT"),
+		SummaryPath: os.Getenv("GITHUB_STEP_SUMMARY"),
+	}
+}
+
+// Event is the part of the event payload that is used to infer what to run
+// the commands against.
+type Event struct {
+	Name string
+
+	// PullRequestNumber is the number of the pull request of pull_request and
+	// pull_request_target events, and zero for every other event.
+	PullRequestNumber int
+
+	// Tag is the name of the tag pushed by a push event, and empty for every
+	// other event.
+	Tag string
+}
+
+type payload struct {
+	Number      int    `json:"number"`
+	Ref         string `json:"ref"`
+	PullRequest *struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+}
+
+// Event reads the payload of the event that triggered the workflow.
+func (r Runner) Event() (Event, error) {
+	if r.EventPath == "" {
+		return Event{}, errors.New("GITHUB_EVENT_PATH is not set, is this running in github actions?")
+	}
+
+	contents, err := ioutil.ReadFile(r.EventPath)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to read event payload: %w", err)
+	}
+
+	return ParseEvent(r.EventName, contents)
+}
+
+// ParseEvent parses the JSON payload of the event with the given name.
+func ParseEvent(name string, contents []byte) (Event, error) {
+	var p payload
+	err := json.Unmarshal(contents, &p)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to parse event payload: %w", err)
+	}
+
+	event := Event{Name: name}
+
+	switch name {
+	case "pull_request", "pull_request_target":
+		event.PullRequestNumber = p.Number
+		if p.PullRequest != nil && p.PullRequest.Number != 0 {
+			event.PullRequestNumber = p.PullRequest.Number
+		}
+
+	case "push":
+		if strings.HasPrefix(p.Ref, "refs/tags/") {
+			event.Tag = strings.TrimPrefix(p.Ref, "refs/tags/")
+		}
+	}
+
+	return event, nil
+}
+
+// SetOutput writes a step output, which can be used by later steps through
+// steps.<id>.outputs.<name>. Nothing is written if the runner has no output
+// file.
+func (r Runner) SetOutput(name, value string) error {
+	if r.OutputPath == "" {
+		return nil
+	}
+
+	delimiter, err := newDelimiter(value)
+	if err != nil {
+		return err
+	}
+
+	// The heredoc syntax is used for every value so that multiline values,
+	// such as the release note, do not need to be treated differently
+	return appendToFile(r.OutputPath, fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter))
+}
+
+// AddSummary appends markdown to the summary of the job. Nothing is written
+// if the runner has no summary file.
+func (r Runner) AddSummary(markdown string) error {
+	if r.SummaryPath == "" {
+		return nil
+	}
+
+	return appendToFile(r.SummaryPath, strings.TrimSuffix(markdown, "\n")+"\n")
+}
+
+// newDelimiter returns a random delimiter for the heredoc of the value that
+// does not appear within the value
+func newDelimiter(value string) (string, error) {
+	for {
+		random := make([]byte, 16)
+		_, err := rand.Read(random)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate output delimiter: %w", err)
+		}
+
+		delimiter := "ghadelimiter_" + hex.EncodeToString(random)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+func appendToFile(path, contents string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(contents)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
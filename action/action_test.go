@@ -0,0 +1,107 @@
+package action_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/clarafu/release-me/action"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAction(t *testing.T) {
+	suite.Run(t, &ActionSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type ActionSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	dir string
+}
+
+func (s *ActionSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+}
+
+func (s *ActionSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *ActionSuite) TestPullRequestEvent() {
+	for _, name := range []string{"pull_request", "pull_request_target"} {
+		event, err := action.Runner{EventName: name, EventPath: "testdata/pull_request.json"}.Event()
+		s.NoError(err)
+		s.Equal(action.Event{Name: name, PullRequestNumber: 1234}, event)
+	}
+}
+
+func (s *ActionSuite) TestPushTagEvent() {
+	event, err := action.Runner{EventName: "push", EventPath: "testdata/push_tag.json"}.Event()
+	s.NoError(err)
+	s.Equal(action.Event{Name: "push", Tag: "v6.4.0"}, event)
+}
+
+func (s *ActionSuite) TestPushBranchEvent() {
+	event, err := action.Runner{EventName: "push", EventPath: "testdata/push_branch.json"}.Event()
+	s.NoError(err)
+	s.Equal(action.Event{Name: "push"}, event)
+}
+
+func (s *ActionSuite) TestOtherEvent() {
+	event, err := action.Runner{EventName: "workflow_dispatch", EventPath: "testdata/pull_request.json"}.Event()
+	s.NoError(err)
+	s.Equal(action.Event{Name: "workflow_dispatch"}, event)
+}
+
+func (s *ActionSuite) TestEventWithoutPayload() {
+	_, err := action.Runner{EventName: "push"}.Event()
+	s.Error(err)
+
+	_, err = action.Runner{EventName: "push", EventPath: filepath.Join(s.dir, "missing.json")}.Event()
+	s.Error(err)
+}
+
+func (s *ActionSuite) TestSetOutput() {
+	runner := action.Runner{OutputPath: filepath.Join(s.dir, "output")}
+
+	s.NoError(runner.SetOutput("version", "v6.4.0"))
+	s.NoError(runner.SetOutput("release-note", "## Features\n\n* Something new\n"))
+
+	contents, err := ioutil.ReadFile(runner.OutputPath)
+	s.NoError(err)
+	s.Regexp(regexp.MustCompile(`^version<<(ghadelimiter_[0-9a-f]+)
+v6\.4\.0
+ghadelimiter_[0-9a-f]+
+release-note<<(ghadelimiter_[0-9a-f]+)
+## Features
+
+\* Something new
+
+ghadelimiter_[0-9a-f]+
+$`), string(contents))
+}
+
+func (s *ActionSuite) TestAddSummary() {
+	runner := action.Runner{SummaryPath: filepath.Join(s.dir, "summary")}
+
+	s.NoError(runner.AddSummary("### First"))
+	s.NoError(runner.AddSummary("### Second\n"))
+
+	contents, err := ioutil.ReadFile(runner.SummaryPath)
+	s.NoError(err)
+	s.Equal("### First\n### Second\n", string(contents))
+}
+
+func (s *ActionSuite) TestWithoutFiles() {
+	runner := action.Runner{}
+	s.NoError(runner.SetOutput("version", "v6.4.0"))
+	s.NoError(runner.AddSummary("### Summary"))
+}
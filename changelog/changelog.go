@@ -0,0 +1,142 @@
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/version"
+)
+
+// Header is written at the top of a changelog that does not exist yet.
+const Header = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.0.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// EntryAlreadyExists is returned when inserting the entry of a version that
+// the changelog already has an entry for.
+type EntryAlreadyExists struct {
+	Version string
+}
+
+func (e EntryAlreadyExists) Error() string {
+	return fmt.Sprintf("changelog already has an entry for version %s", e.Version)
+}
+
+// Templater renders the release note as an entry of a changelog in the Keep a
+// Changelog format. The pull requests of each section are listed under the
+// changelog category of the section in the config.
+type Templater struct {
+	w      io.Writer
+	config config.Config
+}
+
+func NewTemplater(w io.Writer, config config.Config) *Templater {
+	return &Templater{
+		w:      w,
+		config: config,
+	}
+}
+
+func (t *Templater) Render(release generate.Release, sections []generate.Section) error {
+	categories := make(map[string]string)
+	for _, section := range t.config.Sections {
+		categories[section.Title] = section.ChangelogCategory()
+	}
+
+	categoryPRs := make(map[string][]generate.PullRequest)
+	for _, section := range sections {
+		category := categories[section.Title]
+		categoryPRs[category] = append(categoryPRs[category], section.PRs...)
+	}
+
+	entry := new(bytes.Buffer)
+	fmt.Fprintf(entry, "## [%s] - %s\n", release.Version, release.Date.Format("2006-01-02"))
+
+	for _, category := range config.ChangelogCategories {
+		prs := categoryPRs[category]
+		if len(prs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(entry, "\n### %s\n\n", category)
+		for _, pr := range prs {
+			fmt.Fprintf(entry, "- %s (#%d) @%s\n", pr.Title, pr.Number, pr.Author)
+			if pr.ReleaseNote != "" {
+				fmt.Fprintf(entry, "  %s\n", strings.Replace(pr.ReleaseNote, "\n", "\n  ", -1))
+			}
+		}
+	}
+
+	_, err := t.w.Write(entry.Bytes())
+	return err
+}
+
+var (
+	headingRegex        = regexp.MustCompile(`(?m)^## \[?([^\]\s]+)\]?`)
+	linkDefinitionRegex = regexp.MustCompile(`(?m)^\[[^\]]+\]: `)
+)
+
+// Insert adds the entry of the version to the changelog. Entries are ordered
+// from the newest version to the oldest, after the Unreleased entry, so the
+// entry is inserted before the first entry of an older version. If the
+// changelog is empty, the entry is added after the default header. An
+// EntryAlreadyExists error is returned if the changelog already has an entry
+// for the version.
+func Insert(changelog []byte, releaseVersion string, entry []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(changelog)) == 0 {
+		changelog = []byte(Header)
+	}
+
+	newVersion, newErr := version.Parse(releaseVersion)
+
+	insertAt := -1
+	for _, match := range headingRegex.FindAllSubmatchIndex(changelog, -1) {
+		existing := string(changelog[match[2]:match[3]])
+		if strings.EqualFold(existing, "Unreleased") {
+			continue
+		}
+
+		existingVersion, err := version.Parse(existing)
+		if existing == releaseVersion || (newErr == nil && err == nil && existingVersion.Compare(newVersion) == 0) {
+			return nil, EntryAlreadyExists{Version: releaseVersion}
+		}
+
+		// Versions that are not semantic versions cannot be ordered, so the
+		// entry is placed above them as the newest entry
+		if insertAt == -1 && (newErr != nil || err != nil || existingVersion.LessThan(newVersion)) {
+			insertAt = match[0]
+		}
+	}
+
+	// Without an older entry, the entry is the oldest and goes at the end,
+	// above the link definitions of the version headings
+	if insertAt == -1 {
+		if link := linkDefinitionRegex.FindIndex(changelog); link != nil {
+			insertAt = link[0]
+		}
+	}
+
+	inserted := new(bytes.Buffer)
+	if insertAt == -1 {
+		inserted.Write(bytes.TrimRight(changelog, "\n"))
+		inserted.WriteString("\n\n")
+		inserted.Write(bytes.TrimRight(entry, "\n"))
+		inserted.WriteString("\n")
+		return inserted.Bytes(), nil
+	}
+
+	inserted.Write(changelog[:insertAt])
+	inserted.Write(bytes.TrimRight(entry, "\n"))
+	inserted.WriteString("\n\n")
+	inserted.Write(changelog[insertAt:])
+	return inserted.Bytes(), nil
+}
@@ -0,0 +1,154 @@
+package changelog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/changelog"
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/generate"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestChangelog(t *testing.T) {
+	suite.Run(t, &ChangelogSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type ChangelogSuite struct {
+	suite.Suite
+	*require.Assertions
+}
+
+func (s *ChangelogSuite) TestRender() {
+	buf := new(bytes.Buffer)
+	err := changelog.NewTemplater(buf, config.Default).Render(generate.Release{
+		Version: "1.1.0",
+		Date:    time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+	}, []generate.Section{
+		{Title: "Breaking", PRs: []generate.PullRequest{{Title: "Remove the old flag", Number: 4, Author: "alice"}}},
+		{Title: "Features", PRs: []generate.PullRequest{{Title: "Add a feature", Number: 2, Author: "bob", ReleaseNote: "something\nnew"}}},
+		{Title: "Bug Fixes"},
+		{Title: "Miscellaneous", PRs: []generate.PullRequest{{Title: "Bump dependency", Number: 3, Author: "carol"}}},
+	})
+	s.NoError(err)
+	s.Equal(`## [1.1.0] - 2020-06-01
+
+### Added
+
+- Add a feature (#2) @bob
+  something
+  new
+
+### Changed
+
+- Remove the old flag (#4) @alice
+- Bump dependency (#3) @carol
+`, buf.String())
+}
+
+const existing = `# Changelog
+
+## [Unreleased]
+
+- Something not yet released
+
+## [2.0.0] - 2020-05-01
+
+### Changed
+
+- Breaking change (#10) @alice
+
+## [1.0.0] - 2020-01-01
+
+### Added
+
+- Initial release (#1) @alice
+
+[2.0.0]: https://github.com/clarafu/release-me/compare/1.0.0...2.0.0
+`
+
+const entry = `## [VERSION] - 2020-06-01
+
+### Fixed
+
+- Fix a bug (#11) @bob
+`
+
+func (s *ChangelogSuite) TestInsert() {
+	for _, t := range []struct {
+		It       string
+		Version  string
+		Expected string
+	}{
+		{
+			It:      "inserts the newest version after the unreleased entry",
+			Version: "2.1.0",
+			Expected: `# Changelog
+
+## [Unreleased]
+
+- Something not yet released
+
+## [2.1.0] - 2020-06-01
+
+### Fixed
+
+- Fix a bug (#11) @bob
+
+## [2.0.0] - 2020-05-01
+`,
+		},
+		{
+			It:      "inserts an older version before the first older entry",
+			Version: "1.0.1",
+			Expected: `- Breaking change (#10) @alice
+
+## [1.0.1] - 2020-06-01
+
+### Fixed
+
+- Fix a bug (#11) @bob
+
+## [1.0.0] - 2020-01-01
+`,
+		},
+		{
+			It:      "inserts the oldest version above the link definitions",
+			Version: "0.9.0",
+			Expected: `- Initial release (#1) @alice
+
+## [0.9.0] - 2020-06-01
+
+### Fixed
+
+- Fix a bug (#11) @bob
+
+[2.0.0]: https://github.com/clarafu/release-me/compare/1.0.0...2.0.0
+`,
+		},
+	} {
+		s.Run(t.It, func() {
+			changes, err := changelog.Insert([]byte(existing), t.Version, bytes.Replace([]byte(entry), []byte("VERSION"), []byte(t.Version), 1))
+			s.NoError(err)
+			s.Contains(string(changes), t.Expected)
+		})
+	}
+}
+
+func (s *ChangelogSuite) TestInsertIntoEmptyChangelog() {
+	changes, err := changelog.Insert(nil, "1.0.0", []byte("## [1.0.0] - 2020-06-01\n"))
+	s.NoError(err)
+	s.Equal(changelog.Header+"\n## [1.0.0] - 2020-06-01\n", string(changes))
+}
+
+func (s *ChangelogSuite) TestInsertRefusesDuplicateEntries() {
+	_, err := changelog.Insert([]byte(existing), "2.0.0", []byte(entry))
+	s.Equal(changelog.EntryAlreadyExists{Version: "2.0.0"}, err)
+
+	_, err = changelog.Insert([]byte(existing), "v1.0.0", []byte(entry))
+	s.Equal(changelog.EntryAlreadyExists{Version: "v1.0.0"}, err)
+}
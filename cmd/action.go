@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/clarafu/release-me/action"
+	"github.com/clarafu/release-me/logger"
+	"github.com/spf13/cobra"
+)
+
+// githubActionRunner returns the github actions runner that the command is
+// running in, and whether the command should use it as given by the
+// --github-action flag.
+func githubActionRunner(cmd *cobra.Command) (action.Runner, bool) {
+	enabled, _ := cmd.Flags().GetBool("github-action")
+	return action.FromEnv(), enabled
+}
+
+// bindGitHubActionEvent sets the flags that can be inferred from the event
+// that triggered the workflow: the --pr-number of pull_request events and
+// the --release-version of pushed tags.
+func bindGitHubActionEvent(cmd *cobra.Command) error {
+	runner, enabled := githubActionRunner(cmd)
+	if !enabled {
+		return nil
+	}
+
+	// Only read the event for the commands that can infer something from it
+	if cmd.Flags().Lookup("pr-number") == nil && cmd.Flags().Lookup("release-version") == nil {
+		return nil
+	}
+
+	event, err := runner.Event()
+	if err != nil {
+		return err
+	}
+
+	if event.PullRequestNumber != 0 && !cmd.Flags().Changed("pr-number") {
+		logger.Debugf("using pull request #%d of the %s event", event.PullRequestNumber, event.Name)
+
+		err = setIfUnset(cmd, "pr-number", strconv.Itoa(event.PullRequestNumber))
+		if err != nil {
+			return err
+		}
+	}
+
+	if event.Tag != "" && !cmd.Flags().Changed("release-version") {
+		logger.Debugf("using the version of tag %s pushed by the %s event", event.Tag, event.Name)
+
+		err = setIfUnset(cmd, "release-version", event.Tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type output struct {
+	name  string
+	value string
+}
+
+// writeGitHubActionResults writes the step outputs and appends the summary to
+// the job summary if --github-action is set.
+func writeGitHubActionResults(cmd *cobra.Command, summary string, outputs ...output) error {
+	runner, enabled := githubActionRunner(cmd)
+	if !enabled {
+		return nil
+	}
+
+	for _, o := range outputs {
+		err := runner.SetOutput(o.name, o.value)
+		if err != nil {
+			return fmt.Errorf("failed to set step output %s: %w", o.name, err)
+		}
+	}
+
+	err := runner.AddSummary(summary)
+	if err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/provider"
+)
+
+// The exit codes of the CLI, so that wrapper scripts can tell a pull request
+// that needs labels apart from github being unavailable.
+const (
+	ExitFailure                 = 1
+	ExitUnlabelled              = 2
+	ExitPreviousReleaseNotFound = 3
+	ExitAuthenticationFailed    = 4
+	ExitRateLimited             = 5
+	ExitInvalidReleaseNote      = 6
+)
+
+// ExitCode returns the exit code for the error returned by a command.
+func ExitCode(err error) int {
+	var (
+		unlabelled      generate.PullRequestsNotLabelled
+		invalidNote     generate.ReleaseNoteInvalid
+		releaseNotFound provider.PreviousReleaseNotFound
+		authFailed      provider.AuthenticationFailed
+		rateLimited     github.RateLimitError
+	)
+
+	switch {
+	case errors.As(err, &unlabelled):
+		return ExitUnlabelled
+	case errors.As(err, &invalidNote):
+		return ExitInvalidReleaseNote
+	case errors.As(err, &releaseNotFound):
+		return ExitPreviousReleaseNotFound
+	case errors.As(err, &authFailed):
+		return ExitAuthenticationFailed
+	case errors.As(err, &rateLimited):
+		return ExitRateLimited
+	default:
+		return ExitFailure
+	}
+}
@@ -0,0 +1,29 @@
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/clarafu/release-me/cmd"
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		exitCode int
+	}{
+		{errors.New("boom"), cmd.ExitFailure},
+		{fmt.Errorf("failed to generate release note: %w", generate.PullRequestsNotLabelled{}), cmd.ExitUnlabelled},
+		{fmt.Errorf("invalid pull request: %w", generate.ReleaseNoteInvalid{Rule: generate.RuleReleaseNoteEmpty}), cmd.ExitInvalidReleaseNote},
+		{provider.PreviousReleaseNotFound{Branch: "master"}, cmd.ExitPreviousReleaseNotFound},
+		{fmt.Errorf("failed to fetch pull requests: %w", provider.AuthenticationFailed{Provider: "github"}), cmd.ExitAuthenticationFailed},
+		{fmt.Errorf("failed to fetch pull requests: %w", github.RateLimitError{}), cmd.ExitRateLimited},
+	} {
+		require.Equal(t, test.exitCode, cmd.ExitCode(test.err), test.err.Error())
+	}
+}
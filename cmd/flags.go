@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to the name of a flag to find the environment
+// variable it is read from, e.g. RELEASEME_GITHUB_OWNER for --github-owner.
+const envPrefix = "RELEASEME_"
+
+// bindFlags sets the flags that were not given on the command line, so that
+// secrets such as tokens do not have to be passed as arguments. Each flag is
+// read from the first of these that sets it:
+//
+//  1. the command line
+//  2. its RELEASEME_* environment variable
+//  3. the environment variables set by github actions, i.e. GITHUB_TOKEN,
+//     GITHUB_REPOSITORY and GITHUB_API_URL
+//  4. the flags key of the config file
+//  5. the event that triggered the workflow, if --github-action is set,
+//     which is bound separately by bindGitHubActionEvent
+//  6. the default of the flag
+func bindFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed || flag.Name == "help" {
+			return
+		}
+
+		name := envVar(flag.Name)
+		if value, found := os.LookupEnv(name); found {
+			if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = bindGitHubActionsEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, !cmd.Flags().Changed("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var names []string
+	for name := range cfg.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "config" {
+			return fmt.Errorf("the config file can not set the config flag")
+		}
+
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			// The config file is shared by every command, so it can set
+			// flags that only exist on other commands
+			if !isFlag(cmd.Root(), name) {
+				return fmt.Errorf("unknown flag %q in config file", name)
+			}
+			continue
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		err := cmd.Flags().Set(name, cfg.Flags[name])
+		if err != nil {
+			return fmt.Errorf("invalid value %q for flag %q in config file: %w", cfg.Flags[name], name, err)
+		}
+	}
+
+	return nil
+}
+
+// bindGitHubActionsEnv sets the github flags from the environment variables
+// that github actions sets, or that workflows conventionally set in the case
+// of GITHUB_TOKEN.
+func bindGitHubActionsEnv(cmd *cobra.Command) error {
+	err := setIfUnset(cmd, "github-token", os.Getenv("GITHUB_TOKEN"))
+	if err != nil {
+		return err
+	}
+
+	err = setIfUnset(cmd, "github-api-url", os.Getenv("GITHUB_API_URL"))
+	if err != nil {
+		return err
+	}
+
+	if repository := os.Getenv("GITHUB_REPOSITORY"); repository != "" {
+		parts := strings.SplitN(repository, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid GITHUB_REPOSITORY %q, must be owner/repo", repository)
+		}
+
+		err = setIfUnset(cmd, "github-owner", parts[0])
+		if err != nil {
+			return err
+		}
+
+		err = setIfUnset(cmd, "github-repo", parts[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setIfUnset sets the flag if the command has it and it has not been set yet.
+// Empty values are ignored.
+func setIfUnset(cmd *cobra.Command, name, value string) error {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil || flag.Changed || value == "" {
+		return nil
+	}
+	return cmd.Flags().Set(name, value)
+}
+
+// exclusiveFlags returns an error if more than one of the flags is set,
+// whether on the command line, through an environment variable or in the
+// config file.
+func exclusiveFlags(cmd *cobra.Command, flags ...string) error {
+	var set []string
+	for _, flag := range flags {
+		if cmd.Flags().Changed(flag) {
+			set = append(set, `"`+flag+`"`)
+		}
+	}
+
+	if len(set) > 1 {
+		return fmt.Errorf("flags %s can not be used together", strings.Join(set, ", "))
+	}
+
+	return nil
+}
+
+// envVar returns the name of the environment variable the flag is read from
+func envVar(flag string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// isFlag returns true if the command or any of its subcommands has the flag
+func isFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil || cmd.PersistentFlags().Lookup(name) != nil {
+		return true
+	}
+
+	for _, subcommand := range cmd.Commands() {
+		if isFlag(subcommand, name) {
+			return true
+		}
+	}
+
+	return false
+}
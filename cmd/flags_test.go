@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFlags(t *testing.T) {
+	suite.Run(t, &FlagsSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type FlagsSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	dir string
+	env map[string]string
+}
+
+func (s *FlagsSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+
+	s.env = map[string]string{}
+	for _, name := range []string{"GITHUB_TOKEN", "GITHUB_REPOSITORY", "GITHUB_API_URL", "GITHUB_EVENT_NAME", "GITHUB_EVENT_PATH", "RELEASEME_GITHUB_OWNER", "RELEASEME_GITHUB_TOKEN", "RELEASEME_IGNORE_AUTHORS"} {
+		s.env[name] = os.Getenv(name)
+		os.Unsetenv(name)
+	}
+}
+
+func (s *FlagsSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+
+	for name, value := range s.env {
+		os.Setenv(name, value)
+	}
+}
+
+// command returns a command with the flags bound after parsing the arguments
+func (s *FlagsSuite) command(config string, args ...string) (*cobra.Command, error) {
+	configPath := filepath.Join(s.dir, ".releaseme.yml")
+	s.NoError(ioutil.WriteFile(configPath, []byte(config), 0644))
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("config", configPath, "")
+	cmd.Flags().String("github-owner", "", "")
+	cmd.Flags().String("github-repo", "", "")
+	cmd.Flags().String("github-token", "", "")
+	cmd.Flags().String("github-api-url", "", "")
+	cmd.Flags().StringSlice("ignore-authors", nil, "")
+	cmd.Flags().Bool("contributors", false, "")
+	cmd.Flags().Bool("github-action", false, "")
+	cmd.Flags().Int("pr-number", 0, "")
+	cmd.Flags().String("release-version", "", "")
+	cmd.Flags().String("to", "", "")
+	cmd.Flags().String("last-commit-SHA", "", "")
+	s.NoError(cmd.ParseFlags(args))
+
+	return cmd, bindFlags(cmd)
+}
+
+func (s *FlagsSuite) TestPrecedence() {
+	os.Setenv("RELEASEME_GITHUB_TOKEN", "releaseme-token")
+	os.Setenv("GITHUB_TOKEN", "actions-token")
+	os.Setenv("GITHUB_REPOSITORY", "actions-owner/actions-repo")
+	os.Setenv("RELEASEME_GITHUB_OWNER", "releaseme-owner")
+
+	cmd, err := s.command(`
+flags:
+  github-owner: config-owner
+  github-repo: config-repo
+  github-api-url: https://github.example.com/api/v3
+  contributors: true
+  ignore-authors: [dependabot, renovate]
+`, "--github-owner=flag-owner")
+	s.NoError(err)
+
+	owner, _ := cmd.Flags().GetString("github-owner")
+	s.Equal("flag-owner", owner)
+
+	token, _ := cmd.Flags().GetString("github-token")
+	s.Equal("releaseme-token", token)
+
+	repo, _ := cmd.Flags().GetString("github-repo")
+	s.Equal("actions-repo", repo)
+
+	apiURL, _ := cmd.Flags().GetString("github-api-url")
+	s.Equal("https://github.example.com/api/v3", apiURL)
+
+	contributors, _ := cmd.Flags().GetBool("contributors")
+	s.True(contributors)
+
+	ignoreAuthors, _ := cmd.Flags().GetStringSlice("ignore-authors")
+	s.Equal([]string{"dependabot", "renovate"}, ignoreAuthors)
+}
+
+func (s *FlagsSuite) TestEnvironmentVariables() {
+	os.Setenv("RELEASEME_IGNORE_AUTHORS", "dependabot,renovate")
+	os.Setenv("GITHUB_TOKEN", "actions-token")
+	os.Setenv("GITHUB_REPOSITORY", "concourse/concourse")
+
+	cmd, err := s.command("")
+	s.NoError(err)
+
+	ignoreAuthors, _ := cmd.Flags().GetStringSlice("ignore-authors")
+	s.Equal([]string{"dependabot", "renovate"}, ignoreAuthors)
+
+	token, _ := cmd.Flags().GetString("github-token")
+	s.Equal("actions-token", token)
+
+	owner, _ := cmd.Flags().GetString("github-owner")
+	s.Equal("concourse", owner)
+
+	repo, _ := cmd.Flags().GetString("github-repo")
+	s.Equal("concourse", repo)
+}
+
+func (s *FlagsSuite) TestInvalidValues() {
+	os.Setenv("GITHUB_REPOSITORY", "concourse")
+	_, err := s.command("")
+	s.EqualError(err, `invalid GITHUB_REPOSITORY "concourse", must be owner/repo`)
+	os.Unsetenv("GITHUB_REPOSITORY")
+
+	_, err = s.command("flags: {contributors: maybe}")
+	s.Error(err)
+
+	_, err = s.command("flags: {github-ownr: concourse}")
+	s.EqualError(err, `unknown flag "github-ownr" in config file`)
+
+	_, err = s.command("flags: {config: other.yml}")
+	s.Error(err)
+}
+
+func (s *FlagsSuite) TestGitHubActionEvent() {
+	os.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	os.Setenv("GITHUB_EVENT_PATH", "../action/testdata/pull_request.json")
+
+	cmd, err := s.command("", "--github-action")
+	s.NoError(err)
+	s.NoError(bindGitHubActionEvent(cmd))
+
+	prNumber, _ := cmd.Flags().GetInt("pr-number")
+	s.Equal(1234, prNumber)
+
+	cmd, err = s.command("", "--github-action", "--pr-number=7")
+	s.NoError(err)
+	s.NoError(bindGitHubActionEvent(cmd))
+
+	prNumber, _ = cmd.Flags().GetInt("pr-number")
+	s.Equal(7, prNumber)
+
+	os.Setenv("GITHUB_EVENT_NAME", "push")
+	os.Setenv("GITHUB_EVENT_PATH", "../action/testdata/push_tag.json")
+
+	cmd, err = s.command("", "--github-action")
+	s.NoError(err)
+	s.NoError(bindGitHubActionEvent(cmd))
+
+	releaseVersion, _ := cmd.Flags().GetString("release-version")
+	s.Equal("v6.4.0", releaseVersion)
+
+	cmd, err = s.command("")
+	s.NoError(err)
+	s.NoError(bindGitHubActionEvent(cmd))
+
+	releaseVersion, _ = cmd.Flags().GetString("release-version")
+	s.Empty(releaseVersion)
+}
+
+func (s *FlagsSuite) TestExclusiveFlags() {
+	cmd, err := s.command("", "--to=v1.0.0")
+	s.NoError(err)
+	s.NoError(exclusiveFlags(cmd, "last-commit-SHA", "to"))
+
+	cmd, err = s.command("", "--to=v1.0.0", "--last-commit-SHA=abc123")
+	s.NoError(err)
+	s.EqualError(exclusiveFlags(cmd, "last-commit-SHA", "to"), `flags "last-commit-SHA", "to" can not be used together`)
+
+	cmd, err = s.command("flags: {last-commit-SHA: abc123}", "--to=v1.0.0")
+	s.NoError(err)
+	s.EqualError(exclusiveFlags(cmd, "last-commit-SHA", "to"), `flags "last-commit-SHA", "to" can not be used together`)
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
-	"regexp"
+	"time"
 
+	"github.com/clarafu/release-me/changelog"
 	"github.com/clarafu/release-me/generate"
 	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+	"github.com/clarafu/release-me/version"
 	"github.com/spf13/cobra"
 )
 
@@ -15,82 +23,310 @@ var generateCmd = &cobra.Command{
 	Short: "Generates a release note using pull requests",
 	Long: `A release note is generated through fetching all the pull requests
 	merged after the latest tag (release) of the repository. The release note
-	is outputted to stdout.`,
-	Run: generateReleaseNote,
+	is outputted to stdout. With --github-action, the release version defaults
+	to the tag pushed by the event that triggered the workflow.`,
+	RunE: generateReleaseNote,
 }
 
 func init() {
 	generateCmd.Flags().String("github-branch", "master", "the branch name of the github repository to pull the pull requests from")
 	generateCmd.Flags().String("last-commit-SHA", "", "will generate a release note using all prs merged up to this commit SHA. If empty, will generate release note until latest commit.")
+	generateCmd.Flags().String("from", "", "the tag, branch or commit SHA to generate the release note from, excluding its pull requests. If empty, starts from the previous release.")
+	generateCmd.Flags().String("to", "", "the tag, branch or commit SHA to generate the release note up to, including its pull requests. If empty, uses the github branch. Can not be used with --last-commit-SHA.")
 	generateCmd.Flags().String("release-version", "", "the version that the release note will be generated for")
 	generateCmd.Flags().StringSlice("ignore-authors", nil, "comma separated list of github handles, any PRs authored by these handles will be ignored.")
 	generateCmd.Flags().String("ignore-release-regex", "", "a regular expression indicating releases to ignore when determining the previous release")
+	generateCmd.Flags().Bool("require-previous-release", false, "fails if no previous release is found, instead of generating the release note from every commit of the branch")
+	addReleaseFlags(generateCmd)
+	generateCmd.Flags().Bool("contributors", false, "adds a section thanking the authors and co-authors of the pull requests, highlighting first-time contributors")
+	generateCmd.Flags().String("output-format", "markdown", "the format of the release note, either markdown or json")
+	generateCmd.Flags().String("template", "", "path to a go template file used to render the release note. Overrides the template in the config file.")
+	generateCmd.Flags().String("changelog-file", "", "path to a changelog in the Keep a Changelog format, e.g. CHANGELOG.md, to add the entry of the release version to. The file is created if it does not exist.")
+	generateCmd.Flags().Bool("publish", false, "creates a github release for the release version using the generated release note. An existing draft release for the version is updated instead.")
+	generateCmd.Flags().Bool("draft", false, "publishes the github release as a draft")
+	generateCmd.Flags().Bool("prerelease", false, "marks the published github release as a prerelease. Always true if the release version is a prerelease, e.g. 1.0.0-rc.1")
+	generateCmd.Flags().String("target-commitish", "", "the branch or commit SHA the tag of the published github release is created from, if the tag does not exist yet. Defaults to the github branch.")
 	generateCmd.MarkFlagRequired("release-version")
 }
 
-func generateReleaseNote(cmd *cobra.Command, args []string) {
-	githubToken, _ := cmd.Flags().GetString("github-token")
+func generateReleaseNote(cmd *cobra.Command, args []string) error {
+	// Both flags end the range, so they would silently override each other
+	err := exclusiveFlags(cmd, "last-commit-SHA", "to")
+	if err != nil {
+		return err
+	}
 
-	client := github.New(githubToken)
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
 
-	githubOwner, _ := cmd.Flags().GetString("github-owner")
-	githubRepo, _ := cmd.Flags().GetString("github-repo")
+	templatePath, _ := cmd.Flags().GetString("template")
+	if templatePath == "" {
+		templatePath = cfg.Template
+	}
 
-	ignoreReleaseRegexStr, _ := cmd.Flags().GetString("ignore-release-regex")
+	// When publishing or running in github actions, the release note is
+	// still written to stdout but is also kept to be used as the body of the
+	// github release and as a step output
+	publish, _ := cmd.Flags().GetBool("publish")
+	_, githubAction := githubActionRunner(cmd)
+	releaseNote := new(bytes.Buffer)
+	var out io.Writer = os.Stdout
+	if publish || githubAction {
+		out = io.MultiWriter(os.Stdout, releaseNote)
+	}
 
-	// Fetch previous 50 releases from the repository and grab the commit hash
-	// associated to each release
-	releaseSHAs, err := client.FetchCommitsFromReleases(githubOwner, githubRepo)
+	var templater generate.Template
+	switch outputFormat, _ := cmd.Flags().GetString("output-format"); outputFormat {
+	case "markdown":
+		templater = generate.NewReleaseNoteTemplater(out)
+		if templatePath != "" {
+			templater, err = generate.NewReleaseNoteTemplaterFromFile(out, templatePath)
+			if err != nil {
+				return fmt.Errorf("failed to load template: %w", err)
+			}
+		}
+	case "json":
+		if publish {
+			return fmt.Errorf("a release note in json cannot be published")
+		}
+		templater = generate.NewJSONTemplater(out)
+	default:
+		return fmt.Errorf("unknown output format %q, must be markdown or json", outputFormat)
+	}
+
+	p, githubOwner, githubRepo, err := newProvider(cmd)
 	if err != nil {
-		failf("failed to fetch release commit SHAs from github: %s", err)
+		return err
 	}
 
-	if ignoreReleaseRegexStr != "" {
-		filteredReleaseSHAs := make(map[string]string)
-		ignoreReleaseRegex, err := regexp.Compile(ignoreReleaseRegexStr)
+	// Publishing always requires github, even if the release note is
+	// generated using another provider
+	var client github.GitHub
+	if publish {
+		client, githubOwner, githubRepo, err = newGitHubClient(cmd)
 		if err != nil {
-			failf("invalid regex in --ignore-release-regex: %s", err)
-		}
-		for oid, release := range releaseSHAs {
-			if !ignoreReleaseRegex.MatchString(release) {
-				filteredReleaseSHAs[oid] = release
-			}
+			return err
 		}
-		releaseSHAs = filteredReleaseSHAs
+	}
+
+	// Fetch all releases from the repository and grab the commit hash
+	// associated to each release
+	releaseSHAs, err := fetchReleaseSHAs(cmd, p, githubOwner, githubRepo)
+	if err != nil {
+		return err
 	}
 
 	githubBranch, _ := cmd.Flags().GetString("github-branch")
+	lastCommitSHA, _ := cmd.Flags().GetString("last-commit-SHA")
+
+	// The history is walked from the end of the range, which is the branch
+	// unless a revision to generate up to was given
+	head := githubBranch
+	if to, _ := cmd.Flags().GetString("to"); to != "" {
+		toSHA, err := p.ResolveRevision(githubOwner, githubRepo, to)
+		if err != nil {
+			return fmt.Errorf("failed to resolve --to revision: %w", err)
+		}
+		head, lastCommitSHA = toSHA, toSHA
+	} else if lastCommitSHA == "" {
+		// Resolve the branch up front so that the range of the release note
+		// does not move if commits are pushed while it is generated
+		lastCommitSHA, err = p.ResolveRevision(githubOwner, githubRepo, githubBranch)
+		if err != nil {
+			return fmt.Errorf("failed to resolve branch %s: %w", githubBranch, err)
+		}
+	}
 
-	// Starting from the latest commit on the branch, we want to walk backwards
-	// and compare each commit SHA to the list of release commit SHAs. Once we
-	// find a match, this is the the point at which we want to start generating
-	// the release notes for.
 	versionToRelease, _ := cmd.Flags().GetString("release-version")
-	startingCommitSHA, err := client.FetchLatestReleaseCommitFromBranch(githubOwner, githubRepo, githubBranch, versionToRelease, releaseSHAs)
-	if err != nil {
-		failf("failed to fetch latest release commit from branch: %s", err)
+
+	// The starting commit is the oldest commit of the branch if no previous
+	// release was found, in which case the previous release SHA is left empty
+	var startingCommitSHA, previousReleaseSHA, previousVersion string
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		startingCommitSHA, err = p.ResolveRevision(githubOwner, githubRepo, from)
+		if err != nil {
+			return fmt.Errorf("failed to resolve --from revision: %w", err)
+		}
+
+		previousReleaseSHA = startingCommitSHA
+		previousVersion = releaseSHAs[startingCommitSHA]
+		if previousVersion == "" {
+			previousVersion = from
+		}
+
+		logger.Debugf("starting from --from %s at commit %s", from, startingCommitSHA)
+	} else {
+		// Starting from the latest commit on the branch, we want to walk
+		// backwards and compare each commit SHA to the list of release commit
+		// SHAs. Once we find a match, this is the the point at which we want to
+		// start generating the release notes for.
+		startingCommitSHA, err = p.FetchLatestReleaseCommitFromBranch(githubOwner, githubRepo, head, versionToRelease, releaseSHAs)
+		if err != nil {
+			return fmt.Errorf("failed to fetch latest release commit from branch: %w", err)
+		}
+
+		previousVersion = releaseSHAs[startingCommitSHA]
+		if previousVersion != "" {
+			previousReleaseSHA = startingCommitSHA
+			logger.Debugf("starting from release %s at commit %s", previousVersion, startingCommitSHA)
+		} else {
+			if requirePreviousRelease, _ := cmd.Flags().GetBool("require-previous-release"); requirePreviousRelease {
+				return provider.PreviousReleaseNotFound{Branch: head}
+			}
+
+			logger.Warnf("could not find a commit from the latest release, generating release note using all commits in branch %s", head)
+			logger.Debugf("starting from the oldest commit %s, since no previous release was found", startingCommitSHA)
+		}
 	}
 
-	lastCommitSHA, _ := cmd.Flags().GetString("last-commit-SHA")
 	ignoreAuthors, _ := cmd.Flags().GetStringSlice("ignore-authors")
 
 	// Fetch all pull requests that are associated to a commit after the starting
 	// commit SHA. If the pull request is already used for a patch release, it is
 	// not included.
-	pullRequests, err := client.FetchPullRequestsAfterCommit(githubOwner, githubRepo, githubBranch, startingCommitSHA, lastCommitSHA, ignoreAuthors)
+	pullRequests, err := p.FetchPullRequestsAfterCommit(githubOwner, githubRepo, head, startingCommitSHA, lastCommitSHA, ignoreAuthors)
 	if err != nil {
-		failf("failed to fetch pull requests: %s", err)
+		return fmt.Errorf("failed to fetch pull requests: %w", err)
+	}
+
+	release := generate.Release{
+		Version:         versionToRelease,
+		PreviousVersion: previousVersion,
+		Date:            time.Now(),
+		CompareURL:      compareURL(cmd, githubOwner, githubRepo, previousVersion, versionToRelease),
+		Branch:          githubBranch,
+		From:            previousReleaseSHA,
+		To:              lastCommitSHA,
+	}
+
+	if credit, _ := cmd.Flags().GetBool("contributors"); credit {
+		release.Contributors, err = fetchContributors(p, githubOwner, githubRepo, previousReleaseSHA, pullRequests)
+		if err != nil {
+			return fmt.Errorf("failed to fetch contributors: %w", err)
+		}
+	}
+
+	// The changelog entry is rendered from the same sections as the release
+	// note, and only inserted into the changelog once both are rendered
+	changelogPath, _ := cmd.Flags().GetString("changelog-file")
+	changelogEntry := new(bytes.Buffer)
+	if changelogPath != "" {
+		templater = generate.MultiTemplate(templater, changelog.NewTemplater(changelogEntry, cfg))
 	}
 
-	g := generate.New(generate.NewReleaseNoteTemplater(os.Stdout))
+	g := generate.New(templater, cfg)
 
-	err = g.Generate(pullRequests)
+	err = g.Generate(release, pullRequests)
 	if err != nil {
-		failf("failed to generate release note: %s", err)
+		return fmt.Errorf("failed to generate release note: %w", err)
+	}
+
+	if changelogPath != "" {
+		err = writeChangelog(changelogPath, versionToRelease, changelogEntry.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to update changelog: %w", err)
+		}
+
+		logger.Infof("added release %s to %s", versionToRelease, changelogPath)
+	}
+
+	outputs := []output{
+		{"version", versionToRelease},
+		{"previous-version", previousVersion},
+		{"release-note", releaseNote.String()},
+	}
+
+	if publish {
+		draft, _ := cmd.Flags().GetBool("draft")
+		prerelease, _ := cmd.Flags().GetBool("prerelease")
+		if releaseVersion, err := version.Parse(versionToRelease); err == nil && releaseVersion.IsPrerelease() {
+			prerelease = true
+		}
+
+		targetCommitish, _ := cmd.Flags().GetString("target-commitish")
+		if targetCommitish == "" {
+			targetCommitish = githubBranch
+		}
+
+		githubRelease, err := client.PublishRelease(githubOwner, githubRepo, github.Release{
+			TagName:         versionToRelease,
+			TargetCommitish: targetCommitish,
+			Name:            versionToRelease,
+			Body:            releaseNote.String(),
+			Draft:           draft,
+			Prerelease:      prerelease,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish release: %w", err)
+		}
+
+		logger.Infof("published release %s", githubRelease.HTMLURL)
+		outputs = append(outputs, output{"release-url", githubRelease.HTMLURL})
+	}
+
+	summary := releaseNote.String()
+	if outputFormat, _ := cmd.Flags().GetString("output-format"); outputFormat == "json" {
+		summary = "```json\n" + summary + "```"
 	}
+
+	return writeGitHubActionResults(cmd, summary, outputs...)
 }
 
-func failf(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
-	os.Exit(1)
+// fetchContributors returns the contributors of the pull requests. A
+// contributor is highlighted as a first-time contributor if they had no pull
+// request merged before the previous release. Co-authors that are only known
+// by their name cannot be looked up, so they are never highlighted.
+func fetchContributors(p provider.Provider, owner, repo, previousReleaseSHA string, pullRequests []provider.PullRequest) ([]generate.Contributor, error) {
+	contributors := generate.Contributors(pullRequests)
+
+	// The previous release is only fetched once, rather than for every
+	// contributor, and only if there is a contributor to look up
+	var previousRelease *provider.Commit
+	for i, contributor := range contributors {
+		if contributor.Login == "" {
+			continue
+		}
+
+		// Without a previous release, everyone is contributing for the first
+		// time
+		if previousReleaseSHA == "" {
+			contributors[i].FirstTime = true
+			continue
+		}
+
+		if previousRelease == nil {
+			commit, err := p.FetchCommit(owner, repo, previousReleaseSHA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch previous release commit: %w", err)
+			}
+			previousRelease = &commit
+		}
+
+		contributed, err := p.HasMergedPullRequestBefore(owner, repo, contributor.Login, *previousRelease)
+		if err != nil {
+			return nil, err
+		}
+
+		contributors[i].FirstTime = !contributed
+	}
+
+	return contributors, nil
+}
+
+// writeChangelog inserts the entry of the release into the changelog at the
+// given path.
+func writeChangelog(path, releaseVersion string, entry []byte) error {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	changes, err := changelog.Insert(existing, releaseVersion, entry)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, changes, 0644)
 }
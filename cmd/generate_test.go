@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContributions is a provider that knows which authors had a pull
+// request merged before the previous release, recording every commit fetched
+// and every lookup
+type fakeContributions struct {
+	provider.Provider
+
+	contributed map[string]bool
+	lookups     *[]string
+}
+
+func (f fakeContributions) FetchCommit(owner, repo, commitSHA string) (provider.Commit, error) {
+	*f.lookups = append(*f.lookups, commitSHA)
+	return provider.Commit{SHA: commitSHA}, nil
+}
+
+func (f fakeContributions) HasMergedPullRequestBefore(owner, repo, author string, commit provider.Commit) (bool, error) {
+	*f.lookups = append(*f.lookups, author+"@"+commit.SHA)
+	return f.contributed[author], nil
+}
+
+func TestFetchContributors(t *testing.T) {
+	pullRequests := []provider.PullRequest{
+		{Number: 1, Author: "alice"},
+		{Number: 2, Author: "bob", CoAuthors: []provider.CoAuthor{{Name: "Carol Smith", Email: "carol@example.com"}}},
+	}
+
+	t.Run("looks up contributions before the previous release", func(t *testing.T) {
+		var lookups []string
+		p := fakeContributions{contributed: map[string]bool{"alice": true}, lookups: &lookups}
+
+		contributors, err := fetchContributors(p, "clarafu", "release-me", "sha-1", pullRequests)
+		require.NoError(t, err)
+		require.Equal(t, []generate.Contributor{
+			{Login: "alice", FirstTime: false},
+			{Login: "bob", FirstTime: true},
+			{Name: "Carol Smith"},
+		}, contributors)
+		require.Equal(t, []string{"sha-1", "alice@sha-1", "bob@sha-1"}, lookups)
+	})
+
+	t.Run("treats everyone as first time contributors without a previous release", func(t *testing.T) {
+		var lookups []string
+		p := fakeContributions{contributed: map[string]bool{"alice": true}, lookups: &lookups}
+
+		contributors, err := fetchContributors(p, "clarafu", "release-me", "", pullRequests)
+		require.NoError(t, err)
+		require.Equal(t, []generate.Contributor{
+			{Login: "alice", FirstTime: true},
+			{Login: "bob", FirstTime: true},
+			{Name: "Carol Smith"},
+		}, contributors)
+		require.Empty(t, lookups)
+	})
+}
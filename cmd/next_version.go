@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/version"
+	"github.com/spf13/cobra"
+)
+
+var nextVersionCmd = &cobra.Command{
+	Use:   "next-version",
+	Short: "Suggests the next version using the labels of pull requests",
+	Long: `The next version is computed from the latest release on the branch
+	and the labels of the pull requests merged after it. A major version is
+	suggested if any pull request is breaking, a minor version if any pull
+	request is an enhancement and otherwise a patch. The version is outputted
+	to stdout.`,
+	RunE: nextVersion,
+}
+
+func init() {
+	nextVersionCmd.Flags().String("github-branch", "master", "the branch name of the github repository to find the latest release from")
+	nextVersionCmd.Flags().StringSlice("ignore-authors", nil, "comma separated list of github handles, any PRs authored by these handles will be ignored.")
+	nextVersionCmd.Flags().String("ignore-release-regex", "", "a regular expression indicating releases to ignore when determining the previous release")
+	addReleaseFlags(nextVersionCmd)
+	nextVersionCmd.Flags().String("pre-release", "", "suggests a prerelease version using this identifier, e.g. rc will suggest 1.2.0-rc.1")
+}
+
+func nextVersion(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	p, githubOwner, githubRepo, err := newProvider(cmd)
+	if err != nil {
+		return err
+	}
+
+	releaseSHAs, err := fetchReleaseSHAs(cmd, p, githubOwner, githubRepo)
+	if err != nil {
+		return err
+	}
+
+	// Only releases tagged with a semantic version can be incremented
+	for oid, release := range releaseSHAs {
+		if _, err := version.Parse(release); err != nil {
+			delete(releaseSHAs, oid)
+		}
+	}
+
+	// Unlike when generating a release note, patch releases are not skipped
+	// because the next version always follows the latest release
+	githubBranch, _ := cmd.Flags().GetString("github-branch")
+	previousReleaseSHA, err := p.FetchMostRecentReleaseCommitFromBranch(githubOwner, githubRepo, githubBranch, releaseSHAs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest release commit from branch: %w", err)
+	}
+
+	if previousReleaseSHA != "" {
+		logger.Debugf("starting from release %s at commit %s", releaseSHAs[previousReleaseSHA], previousReleaseSHA)
+	} else {
+		logger.Debugf("no previous release was found, starting from %s", version.Version{})
+	}
+
+	ignoreAuthors, _ := cmd.Flags().GetStringSlice("ignore-authors")
+
+	pullRequests, err := p.FetchPullRequestsAfterCommit(githubOwner, githubRepo, githubBranch, previousReleaseSHA, "", ignoreAuthors)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pull requests: %w", err)
+	}
+
+	prerelease, _ := cmd.Flags().GetString("pre-release")
+
+	// Without a previous release, the first version is the one that follows
+	// 0.0.0
+	var previousVersion version.Version
+	if previousReleaseSHA != "" {
+		previousVersion, err = version.Parse(releaseSHAs[previousReleaseSHA])
+		if err != nil {
+			return fmt.Errorf("failed to parse latest release: %w", err)
+		}
+	}
+
+	next := previousVersion.Next(generate.NextBump(cfg, pullRequests), prerelease)
+	fmt.Println(next)
+
+	return writeGitHubActionResults(cmd,
+		fmt.Sprintf("The next version is `%s`", next),
+		output{"version", next.String()},
+	)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/gitlab"
+	"github.com/clarafu/release-me/local"
+	"github.com/clarafu/release-me/provider"
+	"github.com/spf13/cobra"
+)
+
+// newProvider returns the provider given by the --provider flag, along with
+// the owner and name of the repository
+func newProvider(cmd *cobra.Command) (provider.Provider, string, string, error) {
+	providerName, _ := cmd.Flags().GetString("provider")
+
+	switch providerName {
+	case "github":
+		client, owner, repo, err := newGitHubClient(cmd)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		return client, owner, repo, nil
+
+	case "gitlab":
+		err := requireFlags(cmd, "gitlab-project", "gitlab-token")
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		// The namespace of a project can include subgroups, so the project
+		// is split at its last slash
+		project, _ := cmd.Flags().GetString("gitlab-project")
+		separator := strings.LastIndex(project, "/")
+		if separator <= 0 || separator == len(project)-1 {
+			return nil, "", "", fmt.Errorf("invalid gitlab project %q, must be the path of the project including its namespace, e.g. group/project", project)
+		}
+
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		gitlabToken, _ := cmd.Flags().GetString("gitlab-token")
+
+		return gitlab.New(baseURL, gitlabToken), project[:separator], project[separator+1:], nil
+
+	case "local":
+		repositoryPath, _ := cmd.Flags().GetString("repository-path")
+
+		githubOwner, _ := cmd.Flags().GetString("github-owner")
+		githubRepo, _ := cmd.Flags().GetString("github-repo")
+
+		// The pull requests are only enriched using github if github
+		// credentials are available
+		var enrich *github.GitHub
+		if hasGitHubCredentials(cmd) {
+			client, _, _, err := newGitHubClient(cmd)
+			if err != nil {
+				return nil, "", "", err
+			}
+			enrich = &client
+		}
+
+		return local.New(repositoryPath, enrich), githubOwner, githubRepo, nil
+
+	default:
+		return nil, "", "", fmt.Errorf("invalid provider %q, must be one of github, gitlab or local", providerName)
+	}
+}
+
+// compareURL returns the url comparing the previous release to the version
+// on the provider given by the --provider flag
+func compareURL(cmd *cobra.Command, owner, repo, previousVersion, version string) string {
+	if owner == "" || repo == "" || previousVersion == "" {
+		return ""
+	}
+
+	providerName, _ := cmd.Flags().GetString("provider")
+	if providerName == "gitlab" {
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		return fmt.Sprintf("%s/%s/%s/-/compare/%s...%s", strings.TrimSuffix(baseURL, "/"), owner, repo, previousVersion, version)
+	}
+
+	return fmt.Sprintf("%s/%s/%s/compare/%s...%s", githubWebURL(cmd), owner, repo, previousVersion, version)
+}
+
+// newGitHubClient returns a github client along with the owner and name of
+// the github repository, failing if any of them are not configured
+func newGitHubClient(cmd *cobra.Command) (github.GitHub, string, string, error) {
+	client, err := githubClient(cmd, "github-owner", "github-repo")
+	if err != nil {
+		return github.GitHub{}, "", "", err
+	}
+
+	githubOwner, _ := cmd.Flags().GetString("github-owner")
+	githubRepo, _ := cmd.Flags().GetString("github-repo")
+
+	return client, githubOwner, githubRepo, nil
+}
+
+// githubClient returns a github client, failing if the credentials or any of
+// the required flags are not configured
+func githubClient(cmd *cobra.Command, required ...string) (github.GitHub, error) {
+	githubAppID, _ := cmd.Flags().GetInt64("github-app-id")
+	githubAppInstallationID, _ := cmd.Flags().GetInt64("github-app-installation-id")
+
+	// A token is not needed when authenticating as a github app
+	var app *github.App
+	if githubAppID != 0 {
+		err := requireFlags(cmd, append(required, "github-app-private-key")...)
+		if err != nil {
+			return github.GitHub{}, err
+		}
+
+		if githubAppInstallationID == 0 {
+			return github.GitHub{}, fmt.Errorf(`required flag(s) "github-app-installation-id" not set`)
+		}
+
+		githubAppPrivateKeyPath, _ := cmd.Flags().GetString("github-app-private-key")
+		githubAppPrivateKey, err := ioutil.ReadFile(githubAppPrivateKeyPath)
+		if err != nil {
+			return github.GitHub{}, fmt.Errorf("failed to read github app private key: %w", err)
+		}
+
+		app = &github.App{
+			ID:             githubAppID,
+			InstallationID: githubAppInstallationID,
+			PrivateKey:     githubAppPrivateKey,
+		}
+	} else {
+		err := requireFlags(cmd, append(required, "github-token")...)
+		if err != nil {
+			return github.GitHub{}, err
+		}
+	}
+
+	githubToken, _ := cmd.Flags().GetString("github-token")
+	githubCABundle, _ := cmd.Flags().GetString("github-ca-bundle")
+	githubProxy, _ := cmd.Flags().GetString("github-proxy")
+
+	client, err := github.New(githubToken, github.Options{
+		APIURL:   githubAPIURL(cmd),
+		CABundle: githubCABundle,
+		Proxy:    githubProxy,
+		App:      app,
+	})
+	if err != nil {
+		return github.GitHub{}, fmt.Errorf("failed to create github client: %w", err)
+	}
+
+	return client, nil
+}
+
+// hasGitHubCredentials returns true if either a github token or a github app
+// is configured
+func hasGitHubCredentials(cmd *cobra.Command) bool {
+	githubToken, _ := cmd.Flags().GetString("github-token")
+	githubAppID, _ := cmd.Flags().GetInt64("github-app-id")
+	return githubToken != "" || githubAppID != 0
+}
+
+// requireFlags returns an error if any of the flags are empty. The flags can not be
+// marked as required because they are only required by some providers.
+func requireFlags(cmd *cobra.Command, flags ...string) error {
+	var missing []string
+	for _, flag := range flags {
+		if value, _ := cmd.Flags().GetString(flag); value == "" {
+			missing = append(missing, `"`+flag+`"`)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("required flag(s) %s not set", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// githubAPIURL returns the url of the github rest api given by the
+// --github-api-url flag, which defaults to the GITHUB_API_URL environment
+// variable set by github actions
+func githubAPIURL(cmd *cobra.Command) string {
+	apiURL, _ := cmd.Flags().GetString("github-api-url")
+	return strings.TrimSuffix(apiURL, "/")
+}
+
+// githubWebURL returns the url of the github instance that serves the github
+// rest api
+func githubWebURL(cmd *cobra.Command) string {
+	apiURL := githubAPIURL(cmd)
+	if apiURL == "" || apiURL == github.DefaultAPIURL {
+		return "https://github.com"
+	}
+
+	return strings.TrimSuffix(apiURL, "/api/v3")
+}
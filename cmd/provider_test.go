@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitLabProvider(t *testing.T) {
+	command := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().String("provider", "gitlab", "")
+		cmd.Flags().String("base-url", "https://gitlab.com", "")
+		cmd.Flags().String("gitlab-token", "", "")
+		cmd.Flags().String("gitlab-project", "", "")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	_, _, _, err := newProvider(command())
+	require.EqualError(t, err, `required flag(s) "gitlab-project", "gitlab-token" not set`)
+
+	for _, project := range []string{"project", "/project", "group/"} {
+		_, _, _, err = newProvider(command("--gitlab-token=some-token", "--gitlab-project="+project))
+		require.EqualError(t, err, `invalid gitlab project "`+project+`", must be the path of the project including its namespace, e.g. group/project`)
+	}
+
+	_, owner, repo, err := newProvider(command("--gitlab-token=some-token", "--gitlab-project=group/subgroup/project"))
+	require.NoError(t, err)
+	require.Equal(t, "group/subgroup", owner)
+	require.Equal(t, "project", repo)
+}
+
+func TestGitHubWebURL(t *testing.T) {
+	for _, test := range []struct {
+		apiURL string
+		webURL string
+	}{
+		{"", "https://github.com"},
+		{"https://api.github.com", "https://github.com"},
+		{"https://api.github.com/", "https://github.com"},
+		{"https://github.example.com/api/v3", "https://github.example.com"},
+		{"https://github.example.com/api/v3/", "https://github.example.com"},
+	} {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().String("github-api-url", test.apiURL, "")
+
+		require.Equal(t, test.webURL, githubWebURL(cmd), test.apiURL)
+	}
+}
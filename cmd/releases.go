@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+	"github.com/clarafu/release-me/version"
+	"github.com/spf13/cobra"
+)
+
+func addReleaseFlags(cmd *cobra.Command) {
+	cmd.Flags().String("releases-from", "releases", "where the previous releases are read from: releases, tags, or both. Use tags for repositories that push tags without creating releases.")
+	cmd.Flags().String("tag-pattern", "", "a regular expression selecting which tags count as releases")
+}
+
+// fetchReleaseSHAs returns the name of each previous release keyed by the
+// commit SHA it was released from. The releases are read from the releases
+// and/or tags of the repository, as given by --releases-from, and filtered by
+// --tag-pattern and --ignore-release-regex.
+//
+// The version being released is never a previous release, even if it has
+// already been tagged, as it is when generating from a pushed tag.
+func fetchReleaseSHAs(cmd *cobra.Command, p provider.Provider, owner, repo string) (map[string]string, error) {
+	releasesFrom, _ := cmd.Flags().GetString("releases-from")
+
+	switch releasesFrom {
+	case "releases", "tags", "both":
+	default:
+		return nil, fmt.Errorf("invalid --releases-from %q, must be one of releases, tags or both", releasesFrom)
+	}
+
+	releaseSHAs := map[string]string{}
+
+	if releasesFrom == "tags" || releasesFrom == "both" {
+		tagSHAs, err := p.FetchCommitsFromTags(owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tag commit SHAs: %w", err)
+		}
+
+		for oid, tag := range tagSHAs {
+			releaseSHAs[oid] = tag
+		}
+	}
+
+	// Releases are fetched last so that their names are used when a commit
+	// has both a release and another tag
+	if releasesFrom == "releases" || releasesFrom == "both" {
+		fetchedReleaseSHAs, err := p.FetchCommitsFromReleases(owner, repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch release commit SHAs: %w", err)
+		}
+
+		for oid, release := range fetchedReleaseSHAs {
+			releaseSHAs[oid] = release
+		}
+	}
+
+	if releaseVersion, _ := cmd.Flags().GetString("release-version"); releaseVersion != "" {
+		for oid, release := range releaseSHAs {
+			if sameVersion(release, releaseVersion) {
+				logger.Debugf("ignoring release %s: it is the version being released", release)
+				delete(releaseSHAs, oid)
+			}
+		}
+	}
+
+	tagPattern, _ := cmd.Flags().GetString("tag-pattern")
+	if tagPattern != "" {
+		tagRegex, err := regexp.Compile(tagPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in --tag-pattern: %w", err)
+		}
+
+		for oid, release := range releaseSHAs {
+			if !tagRegex.MatchString(release) {
+				logger.Debugf("ignoring release %s: does not match --tag-pattern", release)
+				delete(releaseSHAs, oid)
+			}
+		}
+	}
+
+	ignoreReleaseRegexStr, _ := cmd.Flags().GetString("ignore-release-regex")
+	if ignoreReleaseRegexStr != "" {
+		ignoreReleaseRegex, err := regexp.Compile(ignoreReleaseRegexStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in --ignore-release-regex: %w", err)
+		}
+
+		for oid, release := range releaseSHAs {
+			if ignoreReleaseRegex.MatchString(release) {
+				logger.Debugf("ignoring release %s: matches --ignore-release-regex", release)
+				delete(releaseSHAs, oid)
+			}
+		}
+	}
+
+	return releaseSHAs, nil
+}
+
+// sameVersion returns true if both names are the same version with the same
+// prefix, ignoring the v in the prefix so that v1.0.0 and 1.0.0 are the same
+func sameVersion(name, other string) bool {
+	if name == other {
+		return true
+	}
+
+	v, err := version.Parse(name)
+	if err != nil {
+		return false
+	}
+
+	o, err := version.Parse(other)
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSuffix(v.Prefix, "v") == strings.TrimSuffix(o.Prefix, "v") && v.Compare(o) == 0
+}
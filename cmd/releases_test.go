@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/clarafu/release-me/provider"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReleases is a provider with releases and tags, which are the only parts
+// of the provider read by fetchReleaseSHAs
+type fakeReleases struct {
+	provider.Provider
+
+	releases map[string]string
+	tags     map[string]string
+}
+
+func (f fakeReleases) FetchCommitsFromReleases(owner, repo string) (map[string]string, error) {
+	return f.releases, nil
+}
+
+func (f fakeReleases) FetchCommitsFromTags(owner, repo string) (map[string]string, error) {
+	return f.tags, nil
+}
+
+func TestFetchReleaseSHAs(t *testing.T) {
+	p := fakeReleases{
+		releases: map[string]string{
+			"sha-1": "v1.0.0",
+			"sha-3": "v2.0.0",
+		},
+		tags: map[string]string{
+			"sha-1": "release-1.0.0",
+			"sha-2": "v1.1.0",
+			"sha-3": "v2.0.0",
+			"sha-4": "web/v1.0.0",
+			"sha-5": "v2.1.0-rc.1",
+		},
+	}
+
+	for _, test := range []struct {
+		it          string
+		args        []string
+		releaseSHAs map[string]string
+		err         string
+	}{
+		{
+			it:          "reads releases by default",
+			releaseSHAs: map[string]string{"sha-1": "v1.0.0", "sha-3": "v2.0.0"},
+		},
+		{
+			it:   "reads tags",
+			args: []string{"--releases-from=tags"},
+			releaseSHAs: map[string]string{
+				"sha-1": "release-1.0.0",
+				"sha-2": "v1.1.0",
+				"sha-3": "v2.0.0",
+				"sha-4": "web/v1.0.0",
+				"sha-5": "v2.1.0-rc.1",
+			},
+		},
+		{
+			it:   "prefers the names of releases when reading both",
+			args: []string{"--releases-from=both"},
+			releaseSHAs: map[string]string{
+				"sha-1": "v1.0.0",
+				"sha-2": "v1.1.0",
+				"sha-3": "v2.0.0",
+				"sha-4": "web/v1.0.0",
+				"sha-5": "v2.1.0-rc.1",
+			},
+		},
+		{
+			it:          "filters by the tag pattern",
+			args:        []string{"--releases-from=tags", "--tag-pattern=^web/"},
+			releaseSHAs: map[string]string{"sha-4": "web/v1.0.0"},
+		},
+		{
+			it:          "filters by the tag pattern and ignored releases",
+			args:        []string{"--releases-from=both", "--tag-pattern=^v", "--ignore-release-regex=-rc"},
+			releaseSHAs: map[string]string{"sha-1": "v1.0.0", "sha-2": "v1.1.0", "sha-3": "v2.0.0"},
+		},
+		{
+			it:          "ignores the version being released",
+			args:        []string{"--releases-from=both", "--release-version=v2.0.0"},
+			releaseSHAs: map[string]string{"sha-1": "v1.0.0", "sha-2": "v1.1.0", "sha-4": "web/v1.0.0", "sha-5": "v2.1.0-rc.1"},
+		},
+		{
+			it:          "ignores the version being released without its prefix",
+			args:        []string{"--releases-from=tags", "--release-version=1.1.0"},
+			releaseSHAs: map[string]string{"sha-1": "release-1.0.0", "sha-3": "v2.0.0", "sha-4": "web/v1.0.0", "sha-5": "v2.1.0-rc.1"},
+		},
+		{
+			it:          "only ignores the version being released with the same prefix",
+			args:        []string{"--releases-from=tags", "--release-version=web/v1.0.0"},
+			releaseSHAs: map[string]string{"sha-1": "release-1.0.0", "sha-2": "v1.1.0", "sha-3": "v2.0.0", "sha-5": "v2.1.0-rc.1"},
+		},
+		{
+			it:   "rejects unknown sources",
+			args: []string{"--releases-from=branches"},
+			err:  `invalid --releases-from "branches", must be one of releases, tags or both`,
+		},
+		{
+			it:   "rejects invalid tag patterns",
+			args: []string{"--tag-pattern=("},
+			err:  "invalid regex in --tag-pattern: error parsing regexp: missing closing ): `(`",
+		},
+	} {
+		t.Run(test.it, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			addReleaseFlags(cmd)
+			cmd.Flags().String("ignore-release-regex", "", "")
+			cmd.Flags().String("release-version", "", "")
+			require.NoError(t, cmd.ParseFlags(test.args))
+
+			releaseSHAs, err := fetchReleaseSHAs(cmd, p, "clarafu", "release-me")
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.releaseSHAs, releaseSHAs)
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/logger"
 	"github.com/spf13/cobra"
 )
 
@@ -9,23 +14,106 @@ var (
 		Use:   "releaseme",
 		Short: "CLI to generate release note for your repository.",
 		Long: `Generates a release note using the pull requests within your
-		repository.`,
+		repository.
+
+		Every flag can also be set through an environment variable, e.g.
+		RELEASEME_GITHUB_TOKEN for --github-token, or under the flags key of
+		the config file.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The flags have been parsed by now, so any error from here on
+			// is not a mistake in the usage of the command
+			cmd.SilenceUsage = true
+
+			err := bindFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = configureLogger(cmd)
+			if err != nil {
+				return err
+			}
+
+			// The event is bound last so that what is inferred from it
+			// is logged using the configured logger
+			return bindGitHubActionEvent(cmd)
+		},
+
+		// Errors are logged by the caller of Execute, which also decides
+		// the exit code
+		SilenceErrors: true,
 	}
 )
 
+// Execute runs the command given by the arguments. The error it returns can
+// be turned into the exit code of the CLI with ExitCode.
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
-	rootCmd.PersistentFlags().String("github-owner", "", "the login field of a github user or organization")
-	rootCmd.PersistentFlags().String("github-repo", "", "the name of the github repository")
-	rootCmd.PersistentFlags().String("github-token", "", "github oauth token to authenticate with")
-
-	rootCmd.MarkFlagRequired("github-token")
-	rootCmd.MarkFlagRequired("github-owner")
-	rootCmd.MarkFlagRequired("github-repo")
+	rootCmd.PersistentFlags().String("github-owner", "", "the login field of a github user or organization. Defaults to the owner in the GITHUB_REPOSITORY environment variable.")
+	rootCmd.PersistentFlags().String("github-repo", "", "the name of the github repository. Defaults to the repository in the GITHUB_REPOSITORY environment variable.")
+	rootCmd.PersistentFlags().String("github-token", "", "github oauth token to authenticate with. Defaults to the GITHUB_TOKEN environment variable.")
+	rootCmd.PersistentFlags().Int64("github-app-id", 0, "id of the github app to authenticate as, instead of using a github token")
+	rootCmd.PersistentFlags().Int64("github-app-installation-id", 0, "id of the installation of the github app on the owner of the repository")
+	rootCmd.PersistentFlags().String("github-app-private-key", "", "path to the PEM encoded private key of the github app")
+	rootCmd.PersistentFlags().String("github-api-url", "", "url of the github rest api, e.g. https://[hostname]/api/v3 for github enterprise server. Defaults to the GITHUB_API_URL environment variable or api.github.com.")
+	rootCmd.PersistentFlags().String("github-ca-bundle", "", "path to a file of PEM encoded certificates to trust when connecting to github")
+	rootCmd.PersistentFlags().String("github-proxy", "", "url of the proxy to connect to github through. Defaults to the HTTPS_PROXY environment variable.")
+	rootCmd.PersistentFlags().String("config", config.DefaultPath, "path to the config file declaring the sections of the release note")
+	rootCmd.PersistentFlags().String("provider", "github", "where the releases and pull requests are read from, either github, gitlab or local. The local provider reads the history of a local clone and only uses github to fetch pull request details if a github token is given.")
+	rootCmd.PersistentFlags().String("repository-path", ".", "path to the local clone of the repository used by the local provider")
+	rootCmd.PersistentFlags().String("base-url", "https://gitlab.com", "the url of the gitlab instance used by the gitlab provider")
+	rootCmd.PersistentFlags().String("gitlab-project", "", "the path of the gitlab project including its namespace, e.g. group/subgroup/project, used by the gitlab provider")
+	rootCmd.PersistentFlags().Bool("explain", false, "explains on stderr how the previous release and the pull requests were chosen, e.g. which releases were skipped and why pull requests were excluded. Same as --log-level=debug.")
+	rootCmd.PersistentFlags().Bool("verbose", false, "alias of --explain")
+	rootCmd.PersistentFlags().String("log-level", "info", "the minimum level of the messages logged to stderr, either debug, info, warn or error")
+	rootCmd.PersistentFlags().String("log-format", "text", "the format of the messages logged to stderr, either text or json")
+	rootCmd.PersistentFlags().Bool("github-action", false, "runs as a step of a github actions workflow, inferring the pull request number or release version from the event that triggered the workflow and writing step outputs and a job summary")
+	rootCmd.PersistentFlags().String("gitlab-token", "", "gitlab access token to authenticate with when using the gitlab provider")
 
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(nextVersionCmd)
+	rootCmd.AddCommand(serveCmd)
+}
+
+// configureLogger sets up the logger from the --log-level and --log-format
+// flags. Messages are always logged to stderr, so that stdout only ever
+// contains the output of the command.
+func configureLogger(cmd *cobra.Command) error {
+	levelName, _ := cmd.Flags().GetString("log-level")
+	level, err := logger.ParseLevel(levelName)
+	if err != nil {
+		return fmt.Errorf("invalid --log-level: %w", err)
+	}
+
+	explain, _ := cmd.Flags().GetBool("explain")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	if explain || verbose {
+		level = logger.LevelDebug
+	}
+
+	format, _ := cmd.Flags().GetString("log-format")
+	l, err := logger.New(os.Stderr, level, format)
+	if err != nil {
+		return fmt.Errorf("invalid --log-format: %w", err)
+	}
+
+	logger.Default = l
+	return nil
+}
+
+// loadConfig reads the config file given by the --config flag. The default
+// config is used if the flag is not set and the default file does not exist.
+func loadConfig(cmd *cobra.Command) (config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	cfg, err := config.Load(configPath, !cmd.Flags().Changed("config"))
+	if err != nil {
+		return config.Config{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return cfg, nil
 }
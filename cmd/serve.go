@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/webhook"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Validates pull request labels and release notes on github webhooks",
+	Long: `Listens for the pull_request webhooks of github and reports whether
+	the labels and release note of the pull request are valid, as checked by
+	the "validate" command, as a status of the head commit of the pull
+	request. Pull requests are validated when they are opened, edited,
+	labelled, unlabelled or pushed to.`,
+	RunE: serve,
+}
+
+func init() {
+	serveCmd.Flags().String("listen-address", ":8080", "the address the server listens for webhooks on")
+	serveCmd.Flags().String("webhook-secret", "", "the secret of the github webhook, used to verify the signature of each webhook")
+	serveCmd.Flags().String("status-context", webhook.DefaultStatusContext, "the context of the commit statuses, which is shown as their name on pull requests")
+	serveCmd.Flags().Bool("in-memory", false, "records the commit statuses in memory instead of reporting them to github, listing them at /statuses. Used to try out the server locally by sending it recorded payloads.")
+}
+
+func serve(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	secret, _ := cmd.Flags().GetString("webhook-secret")
+	statusContext, _ := cmd.Flags().GetString("status-context")
+	inMemory, _ := cmd.Flags().GetBool("in-memory")
+
+	mux := http.NewServeMux()
+
+	var statuses webhook.StatusReporter
+	if inMemory {
+		memory := &webhook.Memory{}
+		mux.Handle("/statuses", memory)
+		statuses = memory
+	} else {
+		if secret == "" {
+			return fmt.Errorf(`required flag(s) "webhook-secret" not set`)
+		}
+
+		// The owner and repository are read from each webhook, so that one
+		// server can validate the pull requests of many repositories
+		client, err := githubClient(cmd)
+		if err != nil {
+			return err
+		}
+		statuses = client
+	}
+
+	mux.Handle("/", webhook.New(cfg, secret, statusContext, statuses))
+
+	listenAddress, _ := cmd.Flags().GetString("listen-address")
+	server := &http.Server{
+		Addr:    listenAddress,
+		Handler: mux,
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	logger.Infof("listening for webhooks on %s", listenAddress)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-shutdown:
+	}
+
+	logger.Infof("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+
+	return nil
+}
@@ -1,21 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/clarafu/release-me/generate"
-	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/logger"
 	"github.com/spf13/cobra"
 )
 
 var validateCmd = &cobra.Command{
 	Use:   "validate",
-	Short: "Validates the pull request has correct labels.",
+	Short: "Validates the pull request has correct labels and release note.",
 	Long: `Ensures that the pull request given has at least one of the labels
 	required to properly generate a release note using the "generate"
-	command.`,
-	Run: validate,
+	command, and that it describes its change in the release note section of
+	its description if its labels require one, as configured by the
+	release_note rules of the config file. With --github-action, the pull
+	request defaults to the one that triggered the workflow.`,
+	RunE: validate,
 }
 
 func init() {
@@ -24,28 +29,74 @@ func init() {
 	validateCmd.MarkFlagRequired("pr-number")
 }
 
-func validate(cmd *cobra.Command, args []string) {
-	githubToken, _ := cmd.Flags().GetString("github-token")
-
-	client := github.New(githubToken)
+func validate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
 
-	githubOwner, _ := cmd.Flags().GetString("github-owner")
-	githubRepo, _ := cmd.Flags().GetString("github-repo")
+	p, githubOwner, githubRepo, err := newProvider(cmd)
+	if err != nil {
+		return err
+	}
 
 	prNumber, err := cmd.Flags().GetInt("pr-number")
 	if err != nil {
-		failf("failed to get pr number: %s", err)
+		return fmt.Errorf("failed to get pr number: %w", err)
 	}
 
-	labels, err := client.FetchLabelsForPullRequest(githubOwner, githubRepo, prNumber)
+	pr, err := p.FetchPullRequest(githubOwner, githubRepo, prNumber)
 	if err != nil {
-		failf("failed fetch labels for pull request: %s", err)
+		return fmt.Errorf("failed to fetch pull request: %w", err)
 	}
 
-	hasValidLabels := generate.Validate(labels)
+	hasValidLabels := generate.Validate(cfg, pr.Labels)
 	if !hasValidLabels {
-		failf("invalid pull request %s", generate.PullRequestsNotLabelled{Identifiers: []string{strconv.Itoa(prNumber)}})
+		err = writeGitHubActionResults(cmd,
+			fmt.Sprintf("❌ Pull request #%d must be labelled with at least one of %s", prNumber, codeList(cfg.ValidLabels())),
+			output{"pr-number", strconv.Itoa(prNumber)},
+			output{"valid", "false"},
+		)
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("invalid pull request %w", generate.PullRequestsNotLabelled{
+			Identifiers: []string{strconv.Itoa(prNumber)},
+			ValidLabels: cfg.ValidLabels(),
+		})
 	}
 
-	fmt.Printf("pull request #%d has valid labels\n", prNumber)
+	err = generate.ValidateReleaseNote(cfg, pr.Labels, pr.Body)
+	var invalidNote generate.ReleaseNoteInvalid
+	if errors.As(err, &invalidNote) {
+		err = writeGitHubActionResults(cmd,
+			fmt.Sprintf("❌ Pull request #%d is invalid, %s", prNumber, invalidNote),
+			output{"pr-number", strconv.Itoa(prNumber)},
+			output{"valid", "false"},
+			output{"failed-rule", invalidNote.Rule},
+		)
+		if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("invalid pull request #%d: %w", prNumber, invalidNote)
+	}
+
+	logger.Infof("pull request #%d has valid labels and release note", prNumber)
+
+	return writeGitHubActionResults(cmd,
+		fmt.Sprintf("✅ Pull request #%d has valid labels and release note", prNumber),
+		output{"pr-number", strconv.Itoa(prNumber)},
+		output{"valid", "true"},
+	)
+}
+
+// codeList formats the values as a comma separated list of inline code
+func codeList(values []string) string {
+	var code []string
+	for _, value := range values {
+		code = append(code, "`"+value+"`")
+	}
+	return strings.Join(code, ", ")
 }
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the location of the config file that is loaded when no
+// other path is given.
+const DefaultPath = ".releaseme.yml"
+
+// Section declares a section of the release note. A pull request is placed
+// into the section if it is labelled with any of the section's labels. If a
+// pull request matches more than one section, the section with the lowest
+// precedence wins.
+//
+// Bump is the part of the version, either major, minor or patch, that pull
+// requests within the section increment when suggesting the next version.
+// It defaults to patch.
+//
+// Changelog is the Keep a Changelog category, such as Added or Fixed, that
+// pull requests within the section are listed under in a changelog. It
+// defaults to Changed.
+//
+// Pull requests that do not match any section are placed into the fallback
+// section. Without a fallback section, every pull request must be labelled.
+type Section struct {
+	Title      string   `yaml:"title"`
+	Icon       string   `yaml:"icon"`
+	Labels     []string `yaml:"labels"`
+	Precedence int      `yaml:"precedence"`
+	Bump       string   `yaml:"bump"`
+	Changelog  string   `yaml:"changelog"`
+	Fallback   bool     `yaml:"fallback"`
+}
+
+// ChangelogCategories are the categories of changes in the Keep a Changelog
+// format, in the order they are listed within a version.
+var ChangelogCategories = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+// ChangelogCategory returns the changelog category of the section, defaulting
+// to Changed.
+func (s Section) ChangelogCategory() string {
+	if s.Changelog == "" {
+		return "Changed"
+	}
+	return s.Changelog
+}
+
+func (s Section) HasLabel(label string) bool {
+	for _, lbl := range s.Labels {
+		if lbl == label {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	// The ordering of this list is the order the sections are rendered in
+	Sections []Section `yaml:"sections"`
+
+	// Template is the path to a go template file used to render the release
+	// note instead of the default template. When loaded from a config file, a
+	// relative path is resolved against the directory of the config file.
+	Template string `yaml:"template"`
+
+	// Flags are the values of command line flags that are not given on the
+	// command line or through an environment variable.
+	Flags Flags `yaml:"flags"`
+
+	// ReleaseNote declares which pull requests must describe their change in
+	// the release note section of their description.
+	ReleaseNote ReleaseNoteRules `yaml:"release_note"`
+}
+
+// ReleaseNoteRules are checked by the validate command against the release
+// note section of the description of a pull request.
+type ReleaseNoteRules struct {
+	// RequiredFor are the labels of pull requests that must have a non-empty
+	// release note
+	RequiredFor []string `yaml:"required_for"`
+
+	// OptOut is the release note, e.g. NONE, that a pull request uses to
+	// explicitly declare that it has nothing worth noting. It is not
+	// rendered in the release note. An empty opt out disables opting out.
+	OptOut string `yaml:"opt_out"`
+}
+
+// IsOptOut returns true if the release note is the opt out, ignoring case
+// and surrounding whitespace.
+func (r ReleaseNoteRules) IsOptOut(releaseNote string) bool {
+	return r.OptOut != "" && strings.EqualFold(strings.TrimSpace(releaseNote), r.OptOut)
+}
+
+// Flags are keyed by the name of the flag without the leading dashes, e.g.
+// github-owner. The values of list flags, such as ignore-authors, can be
+// given as a list and are joined with commas.
+type Flags map[string]string
+
+func (f *Flags) UnmarshalYAML(node *yaml.Node) error {
+	var values map[string]yaml.Node
+	err := node.Decode(&values)
+	if err != nil {
+		return err
+	}
+
+	*f = make(Flags)
+	for name, value := range values {
+		switch value.Kind {
+		case yaml.ScalarNode:
+			(*f)[name] = value.Value
+		case yaml.SequenceNode:
+			var list []string
+			err := value.Decode(&list)
+			if err != nil {
+				return fmt.Errorf("flag %q: %w", name, err)
+			}
+			(*f)[name] = strings.Join(list, ",")
+		default:
+			return fmt.Errorf("flag %q must be a value or a list of values", name)
+		}
+	}
+
+	return nil
+}
+
+// Default is used when there is no config file within the repository.
+var Default = Config{
+	Sections: []Section{
+		{Title: "Breaking", Icon: "🚨", Labels: []string{"breaking"}, Precedence: 1, Bump: "major", Changelog: "Changed"},
+		{Title: "Features", Icon: "✈️", Labels: []string{"enhancement"}, Precedence: 4, Bump: "minor", Changelog: "Added"},
+		{Title: "Bug Fixes", Icon: "🐞", Labels: []string{"bug"}, Precedence: 3, Changelog: "Fixed"},
+		{Title: "Miscellaneous", Icon: "🤷", Labels: []string{"misc"}, Precedence: 2, Changelog: "Changed"},
+	},
+	ReleaseNote: ReleaseNoteRules{
+		RequiredFor: []string{"breaking", "enhancement"},
+		OptOut:      "NONE",
+	},
+}
+
+// Load reads the config file at the given path. If the file does not exist
+// and optional is true, the default config is returned instead.
+func Load(path string, optional bool) (Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return Default, nil
+		}
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config, err := Parse(contents)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if config.Template != "" && !filepath.IsAbs(config.Template) {
+		config.Template = filepath.Join(filepath.Dir(path), config.Template)
+	}
+
+	return config, nil
+}
+
+func Parse(contents []byte) (Config, error) {
+	// The release note rules default to those of the default config, with
+	// each rule only replaced if it is given
+	config := Config{ReleaseNote: Default.ReleaseNote}
+	err := yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if len(config.Sections) == 0 {
+		config.Sections = Default.Sections
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func (c Config) Validate() error {
+	seen := make(map[string]string)
+	titles := make(map[string]bool)
+	var fallback string
+	for i, section := range c.Sections {
+		if section.Title == "" {
+			return fmt.Errorf("section %d is missing a title", i+1)
+		}
+
+		if titles[section.Title] {
+			return fmt.Errorf("section %q is declared more than once", section.Title)
+		}
+		titles[section.Title] = true
+
+		switch section.Bump {
+		case "", "major", "minor", "patch":
+		default:
+			return fmt.Errorf("section %q has invalid bump %q, must be one of major, minor or patch", section.Title, section.Bump)
+		}
+
+		if section.Changelog != "" && !isChangelogCategory(section.Changelog) {
+			return fmt.Errorf("section %q has invalid changelog category %q, must be one of %s", section.Title, section.Changelog, strings.Join(ChangelogCategories, ", "))
+		}
+
+		if section.Fallback {
+			if fallback != "" {
+				return fmt.Errorf("only one section can be the fallback, but both %q and %q are", fallback, section.Title)
+			}
+			fallback = section.Title
+		}
+
+		if len(section.Labels) == 0 && !section.Fallback {
+			return fmt.Errorf("section %q must have at least one label", section.Title)
+		}
+
+		for _, label := range section.Labels {
+			if other, found := seen[label]; found {
+				return fmt.Errorf("label %q is used by both section %q and %q", label, other, section.Title)
+			}
+			seen[label] = section.Title
+		}
+	}
+
+	return nil
+}
+
+func isChangelogCategory(category string) bool {
+	for _, c := range ChangelogCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// SectionsByPrecedence returns the sections ordered by their precedence.
+// Sections with the same precedence keep the order they were declared in.
+func (c Config) SectionsByPrecedence() []Section {
+	sections := make([]Section, len(c.Sections))
+	copy(sections, c.Sections)
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].Precedence < sections[j].Precedence
+	})
+
+	return sections
+}
+
+// ValidLabels returns every label that places a pull request into a section,
+// in order of precedence.
+func (c Config) ValidLabels() []string {
+	var labels []string
+	for _, section := range c.SectionsByPrecedence() {
+		labels = append(labels, section.Labels...)
+	}
+	return labels
+}
@@ -0,0 +1,247 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestConfig(t *testing.T) {
+	suite.Run(t, &ConfigSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type ConfigSuite struct {
+	suite.Suite
+	*require.Assertions
+}
+
+func (s *ConfigSuite) TestParse() {
+	cfg, err := config.Parse([]byte(`
+sections:
+- title: Features
+  icon: "✈️"
+  labels: [enhancement, feature]
+  precedence: 2
+- title: Bug Fixes
+  labels: [bug]
+  precedence: 1
+`))
+	s.NoError(err)
+	s.Equal(config.Config{
+		Sections: []config.Section{
+			{Title: "Features", Icon: "✈️", Labels: []string{"enhancement", "feature"}, Precedence: 2},
+			{Title: "Bug Fixes", Labels: []string{"bug"}, Precedence: 1},
+		},
+		ReleaseNote: config.Default.ReleaseNote,
+	}, cfg)
+	s.Equal([]string{"bug", "enhancement", "feature"}, cfg.ValidLabels())
+}
+
+func (s *ConfigSuite) TestParseWithoutSectionsUsesDefault() {
+	cfg, err := config.Parse([]byte(``))
+	s.NoError(err)
+	s.Equal(config.Default, cfg)
+}
+
+func (s *ConfigSuite) TestDefaultPrecedence() {
+	s.Equal([]string{"breaking", "misc", "bug", "enhancement"}, config.Default.ValidLabels())
+}
+
+func (s *ConfigSuite) TestSectionsWithSamePrecedenceKeepDeclaredOrder() {
+	cfg, err := config.Parse([]byte(`
+sections:
+- title: A
+  labels: [a]
+- title: B
+  labels: [b]
+`))
+	s.NoError(err)
+	s.Equal([]string{"a", "b"}, cfg.ValidLabels())
+}
+
+func (s *ConfigSuite) TestParseInvalid() {
+	for _, t := range []struct {
+		It     string
+		Config string
+		Err    string
+	}{
+		{
+			It: "requires a title",
+			Config: `
+sections:
+- labels: [bug]`,
+			Err: "section 1 is missing a title",
+		},
+		{
+			It: "requires labels",
+			Config: `
+sections:
+- title: Bugs`,
+			Err: `section "Bugs" must have at least one label`,
+		},
+		{
+			It: "rejects duplicate titles",
+			Config: `
+sections:
+- title: Bugs
+  labels: [bug]
+- title: Bugs
+  labels: [regression]`,
+			Err: `section "Bugs" is declared more than once`,
+		},
+		{
+			It: "rejects more than one fallback section",
+			Config: `
+sections:
+- title: Other
+  fallback: true
+- title: Everything Else
+  fallback: true`,
+			Err: `only one section can be the fallback, but both "Other" and "Everything Else" are`,
+		},
+		{
+			It: "rejects unknown bumps",
+			Config: `
+sections:
+- title: Bugs
+  labels: [bug]
+  bump: tiny`,
+			Err: `section "Bugs" has invalid bump "tiny", must be one of major, minor or patch`,
+		},
+		{
+			It: "rejects unknown changelog categories",
+			Config: `
+sections:
+- title: Bugs
+  labels: [bug]
+  changelog: Squashed`,
+			Err: `section "Bugs" has invalid changelog category "Squashed", must be one of Added, Changed, Deprecated, Removed, Fixed, Security`,
+		},
+		{
+			It: "rejects labels used by more than one section",
+			Config: `
+sections:
+- title: Bugs
+  labels: [bug]
+- title: Fixes
+  labels: [bug]`,
+			Err: `label "bug" is used by both section "Bugs" and "Fixes"`,
+		},
+	} {
+		s.Run(t.It, func() {
+			_, err := config.Parse([]byte(t.Config))
+			s.EqualError(err, t.Err)
+		})
+	}
+}
+
+func (s *ConfigSuite) TestFallbackSectionDoesNotRequireLabels() {
+	cfg, err := config.Parse([]byte(`
+sections:
+- title: Other
+  fallback: true
+`))
+	s.NoError(err)
+	s.Equal([]config.Section{{Title: "Other", Fallback: true}}, cfg.Sections)
+}
+
+func (s *ConfigSuite) TestParseFlags() {
+	cfg, err := config.Parse([]byte(`
+flags:
+  github-owner: concourse
+  contributors: true
+  ignore-authors: [dependabot, renovate]
+`))
+	s.NoError(err)
+	s.Equal(config.Flags{
+		"github-owner":   "concourse",
+		"contributors":   "true",
+		"ignore-authors": "dependabot,renovate",
+	}, cfg.Flags)
+
+	_, err = config.Parse([]byte(`
+flags:
+  github-owner: {name: concourse}
+`))
+	s.Error(err)
+}
+
+func (s *ConfigSuite) TestParseReleaseNoteRules() {
+	cfg, err := config.Parse([]byte(`
+release_note:
+  required_for: [breaking]
+`))
+	s.NoError(err)
+	s.Equal(config.ReleaseNoteRules{
+		RequiredFor: []string{"breaking"},
+		OptOut:      "NONE",
+	}, cfg.ReleaseNote)
+
+	cfg, err = config.Parse([]byte(`
+release_note:
+  required_for: []
+  opt_out: N/A
+`))
+	s.NoError(err)
+	s.Equal(config.ReleaseNoteRules{RequiredFor: []string{}, OptOut: "N/A"}, cfg.ReleaseNote)
+}
+
+func (s *ConfigSuite) TestReleaseNoteOptOut() {
+	rules := config.ReleaseNoteRules{OptOut: "NONE"}
+	s.True(rules.IsOptOut("NONE"))
+	s.True(rules.IsOptOut("  none\n"))
+	s.False(rules.IsOptOut("None of the commands changed"))
+	s.False(rules.IsOptOut(""))
+
+	s.False(config.ReleaseNoteRules{}.IsOptOut(""))
+}
+
+func (s *ConfigSuite) TestLoad() {
+	dir, err := ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	missing := filepath.Join(dir, "missing.yml")
+
+	cfg, err := config.Load(missing, true)
+	s.NoError(err)
+	s.Equal(config.Default, cfg)
+
+	_, err = config.Load(missing, false)
+	s.Error(err)
+
+	path := filepath.Join(dir, ".releaseme.yml")
+	s.NoError(ioutil.WriteFile(path, []byte("sections: [{title: Changes, labels: [change]}]"), 0644))
+
+	cfg, err = config.Load(path, false)
+	s.NoError(err)
+	s.Equal([]config.Section{{Title: "Changes", Labels: []string{"change"}}}, cfg.Sections)
+}
+
+func (s *ConfigSuite) TestLoadResolvesTemplateAgainstConfigDir() {
+	dir, err := ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s.NoError(os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	path := filepath.Join(dir, "sub", ".releaseme.yml")
+	s.NoError(ioutil.WriteFile(path, []byte("template: notes.tmpl"), 0644))
+
+	cfg, err := config.Load(path, false)
+	s.NoError(err)
+	s.Equal(filepath.Join(dir, "sub", "notes.tmpl"), cfg.Template)
+
+	s.NoError(ioutil.WriteFile(path, []byte("template: /etc/releaseme/notes.tmpl"), 0644))
+
+	cfg, err = config.Load(path, false)
+	s.NoError(err)
+	s.Equal("/etc/releaseme/notes.tmpl", cfg.Template)
+}
@@ -0,0 +1,65 @@
+package generate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/clarafu/release-me/provider"
+)
+
+// Contributor is someone who authored or co-authored a pull request in the
+// release. Co-authors are only known by their name unless their login can be
+// derived from their email.
+type Contributor struct {
+	Login string
+	Name  string
+
+	// FirstTime is true if the contributor had no pull request merged before
+	// the previous release
+	FirstTime bool
+}
+
+// Contributors returns the authors of the pull requests and the co-authors
+// credited in their commits, ordered by login or name. Each contributor is
+// only listed once.
+func Contributors(prs []provider.PullRequest) []Contributor {
+	var contributors []Contributor
+	seen := make(map[string]bool)
+
+	add := func(contributor Contributor) {
+		key := strings.ToLower(contributor.Login)
+		if key == "" {
+			key = "name:" + strings.ToLower(contributor.Name)
+		}
+
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		contributors = append(contributors, contributor)
+	}
+
+	for _, pr := range prs {
+		if pr.Author != "" {
+			add(Contributor{Login: pr.Author})
+		}
+
+		for _, coAuthor := range pr.CoAuthors {
+			add(Contributor{Login: coAuthor.Login(), Name: coAuthor.Name})
+		}
+	}
+
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return strings.ToLower(contributors[i].displayName()) < strings.ToLower(contributors[j].displayName())
+	})
+
+	return contributors
+}
+
+func (c Contributor) displayName() string {
+	if c.Login != "" {
+		return c.Login
+	}
+	return c.Name
+}
@@ -0,0 +1,30 @@
+package generate_test
+
+import (
+	"testing"
+
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContributors(t *testing.T) {
+	contributors := generate.Contributors([]provider.PullRequest{
+		{Number: 1, Author: "carol"},
+		{
+			Number: 2,
+			Author: "alice",
+			CoAuthors: []provider.CoAuthor{
+				{Name: "Carol", Email: "1+carol@users.noreply.github.com"},
+				{Name: "Bob Smith", Email: "bob@example.com"},
+			},
+		},
+		{Number: 3, Author: "alice", CoAuthors: []provider.CoAuthor{{Name: "Bob Smith", Email: "bob@work.example.com"}}},
+	})
+
+	require.Equal(t, []generate.Contributor{
+		{Login: "alice"},
+		{Name: "Bob Smith"},
+		{Login: "carol"},
+	}, contributors)
+}
@@ -5,19 +5,15 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+	"github.com/clarafu/release-me/version"
 )
 
-// The ordering of this list is the order of precedence of the labels
-var ValidLabels = []string{
-	"breaking",
-	"misc",
-	"bug",
-	"enhancement",
-}
-
 type PullRequestsNotLabelled struct {
 	Identifiers []string
+	ValidLabels []string
 }
 
 func (e PullRequestsNotLabelled) Error() string {
@@ -27,7 +23,7 @@ func (e PullRequestsNotLabelled) Error() string {
 	}
 
 	validLabels := []string{}
-	for _, label := range ValidLabels {
+	for _, label := range e.ValidLabels {
 		validLabels = append(validLabels, "- "+label)
 	}
 
@@ -40,57 +36,129 @@ must be labelled with at least one of:
 %s`, strings.Join(prIdentifiers, "\n"), strings.Join(validLabels, "\n"))
 }
 
+const (
+	// RuleReleaseNoteMissing fails when a pull request that requires a
+	// release note has no release note section in its description
+	RuleReleaseNoteMissing = "release-note-missing"
+
+	// RuleReleaseNoteEmpty fails when a pull request that requires a release
+	// note has a release note section without any content
+	RuleReleaseNoteEmpty = "release-note-empty"
+)
+
+// ReleaseNoteInvalid is returned when the release note of a pull request
+// breaks one of the release note rules of the config.
+type ReleaseNoteInvalid struct {
+	Rule   string
+	Label  string
+	OptOut string
+}
+
+func (e ReleaseNoteInvalid) Error() string {
+	var problem string
+	switch e.Rule {
+	case RuleReleaseNoteMissing:
+		problem = "need a \"## Release Note\" section"
+	default:
+		problem = "need a non-empty \"## Release Note\" section"
+	}
+
+	optOut := ""
+	if e.OptOut != "" {
+		optOut = fmt.Sprintf(", or %q to opt out", e.OptOut)
+	}
+
+	return fmt.Sprintf("rule %s failed: pull requests labelled %q %s%s", e.Rule, e.Label, problem, optOut)
+}
+
 type Template interface {
-	Render(sections []Section) error
+	Render(release Release, sections []Section) error
+}
+
+// MultiTemplate renders the release note with each of the templates in turn,
+// so that the sections are built once for all of them.
+func MultiTemplate(templates ...Template) Template {
+	return multiTemplate(templates)
+}
+
+type multiTemplate []Template
+
+func (m multiTemplate) Render(release Release, sections []Section) error {
+	for _, template := range m {
+		err := template.Render(release, sections)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 type Generator struct {
 	template Template
+	config   config.Config
 }
 
-func New(template Template) Generator {
-	return Generator{template}
+func New(template Template, config config.Config) Generator {
+	return Generator{template, config}
 }
 
-func (g Generator) Generate(prs []github.PullRequest) error {
+func (g Generator) Generate(release Release, prs []provider.PullRequest) error {
 	g.sortPRsByPriority(prs)
 
 	var unlabelledPRUrls []string
 	sectionPRs := make(map[string][]PullRequest)
+	sectionsByPrecedence := g.config.SectionsByPrecedence()
 	for _, githubPR := range prs {
 		pr := PullRequest{
 			Title:       githubPR.Title,
 			Author:      githubPR.Author,
 			Number:      githubPR.Number,
+			URL:         githubPR.Url,
+			Labels:      githubPR.Labels,
 			ReleaseNote: parseReleaseNote(githubPR.Body),
 		}
 
-		var labelled bool
-		for _, label := range ValidLabels {
-			if githubPR.HasLabel(label) {
-				sectionPRs[label] = append(sectionPRs[label], pr)
-				labelled = true
-				break
-			}
+		if g.config.ReleaseNote.IsOptOut(pr.ReleaseNote) {
+			pr.ReleaseNote = ""
+		}
+
+		for _, issue := range githubPR.ClosingIssues {
+			pr.Issues = append(pr.Issues, Issue{
+				Number: issue.Number,
+				URL:    issue.Url,
+			})
+		}
+
+		section, labelled := sectionForLabels(sectionsByPrecedence, githubPR.Labels)
+		if labelled {
+			logger.Debugf("placing pull request #%d into section %s", pr.Number, section.Title)
+			sectionPRs[section.Title] = append(sectionPRs[section.Title], pr)
 		}
 
 		if !labelled {
+			logger.Debugf("pull request #%d has none of the labels of a section", pr.Number)
 			unlabelledPRUrls = append(unlabelledPRUrls, githubPR.Url)
 		}
 	}
 
 	if len(unlabelledPRUrls) > 0 {
-		return PullRequestsNotLabelled{Identifiers: unlabelledPRUrls}
+		return PullRequestsNotLabelled{
+			Identifiers: unlabelledPRUrls,
+			ValidLabels: g.config.ValidLabels(),
+		}
 	}
 
-	sections := []Section{
-		Section{Title: "Breaking", Icon: "🚨", PRs: sectionPRs["breaking"]},
-		Section{Title: "Features", Icon: "✈️", PRs: sectionPRs["enhancement"]},
-		Section{Title: "Bug Fixes", Icon: "🐞", PRs: sectionPRs["bug"]},
-		Section{Title: "Miscellaneous", Icon: "🤷", PRs: sectionPRs["misc"]},
+	var sections []Section
+	for _, section := range g.config.Sections {
+		sections = append(sections, Section{
+			Title: section.Title,
+			Icon:  section.Icon,
+			PRs:   sectionPRs[section.Title],
+		})
 	}
 
-	err := g.template.Render(sections)
+	err := g.template.Render(release, sections)
 	if err != nil {
 		return fmt.Errorf("failed to write release notes: %w", err)
 	}
@@ -98,7 +166,7 @@ func (g Generator) Generate(prs []github.PullRequest) error {
 	return nil
 }
 
-func (g Generator) sortPRsByPriority(prs []github.PullRequest) {
+func (g Generator) sortPRsByPriority(prs []provider.PullRequest) {
 	sort.Slice(prs, func(i, j int) bool {
 		switch prs[i].HasLabel("priority") != prs[j].HasLabel("priority") {
 		case true:
@@ -117,17 +185,98 @@ func (g Generator) sortPRsByPriority(prs []github.PullRequest) {
 	})
 }
 
-func Validate(labels []string) bool {
-	validLabelsMap := make(map[string]bool)
-	for _, validLabel := range ValidLabels {
-		validLabelsMap[validLabel] = true
+// sectionForLabels returns the first section, in the order given, that has
+// any of the labels. If none of the sections have the labels, the fallback
+// section is returned if there is one.
+func sectionForLabels(sections []config.Section, labels []string) (config.Section, bool) {
+	for _, section := range sections {
+		for _, label := range labels {
+			if section.HasLabel(label) {
+				return section, true
+			}
+		}
+	}
+
+	for _, section := range sections {
+		if section.Fallback {
+			return section, true
+		}
 	}
 
-	for _, label := range labels {
-		if _, exists := validLabelsMap[label]; exists {
-			return true
+	return config.Section{}, false
+}
+
+func Validate(config config.Config, labels []string) bool {
+	_, labelled := sectionForLabels(config.Sections, labels)
+	return labelled
+}
+
+// ValidateReleaseNote checks the release note section of the body of a pull
+// request with the labels against the release note rules of the config. Pull
+// requests labelled with any of the labels that require a release note must
+// have a non-empty release note, unless their release note is the opt out.
+func ValidateReleaseNote(config config.Config, labels []string, body string) error {
+	rules := config.ReleaseNote
+
+	var requiredBy string
+	for _, required := range rules.RequiredFor {
+		for _, label := range labels {
+			if label == required {
+				requiredBy = label
+				break
+			}
+		}
+		if requiredBy != "" {
+			break
+		}
+	}
+
+	if requiredBy == "" {
+		return nil
+	}
+
+	releaseNote, found := findReleaseNote(body)
+	if !found {
+		return ReleaseNoteInvalid{
+			Rule:   RuleReleaseNoteMissing,
+			Label:  requiredBy,
+			OptOut: rules.OptOut,
+		}
+	}
+
+	if strings.TrimSpace(releaseNote) == "" {
+		return ReleaseNoteInvalid{
+			Rule:   RuleReleaseNoteEmpty,
+			Label:  requiredBy,
+			OptOut: rules.OptOut,
+		}
+	}
+
+	return nil
+}
+
+// NextBump returns the largest version bump of the sections whose labels the
+// pull requests have. A pull request in several sections bumps the version
+// by the largest of them, even if it is only placed in one of them. Pull
+// requests that are not labelled with a valid label are treated as patches.
+func NextBump(config config.Config, prs []provider.PullRequest) version.Bump {
+	bump := version.BumpPatch
+	for _, pr := range prs {
+		for _, section := range config.Sections {
+			for _, label := range pr.Labels {
+				if !section.HasLabel(label) {
+					continue
+				}
+
+				switch section.Bump {
+				case "major":
+					return version.BumpMajor
+				case "minor":
+					bump = version.BumpMinor
+				}
+			}
 		}
 	}
 
-	return false
+	return bump
 }
@@ -3,9 +3,11 @@ package generate_test
 import (
 	"testing"
 
+	"github.com/clarafu/release-me/config"
 	"github.com/clarafu/release-me/generate"
 	"github.com/clarafu/release-me/generate/mocks"
-	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/provider"
+	"github.com/clarafu/release-me/version"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -25,7 +27,7 @@ type GenerateSuite struct {
 type GenerateTest struct {
 	It string
 
-	PRs []github.PullRequest
+	PRs []provider.PullRequest
 
 	ExpectedBreaking []generate.PullRequest
 	ExpectedFeatures []generate.PullRequest
@@ -40,7 +42,7 @@ func (s *GenerateSuite) TestGenerate() {
 		{
 			It: "groups the PRs by label",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "new breaking change!",
 					Labels: []string{"breaking"},
@@ -59,15 +61,15 @@ func (s *GenerateSuite) TestGenerate() {
 				},
 			},
 
-			ExpectedBreaking: []generate.PullRequest{{Title: "new breaking change!"}},
-			ExpectedFeatures: []generate.PullRequest{{Title: "cool new feature!"}},
-			ExpectedBugFixes: []generate.PullRequest{{Title: "squash that bug!"}},
-			ExpectedMisc:     []generate.PullRequest{{Title: "don't worry about it!"}},
+			ExpectedBreaking: []generate.PullRequest{{Title: "new breaking change!", Labels: []string{"breaking"}}},
+			ExpectedFeatures: []generate.PullRequest{{Title: "cool new feature!", Labels: []string{"enhancement"}}},
+			ExpectedBugFixes: []generate.PullRequest{{Title: "squash that bug!", Labels: []string{"bug"}}},
+			ExpectedMisc:     []generate.PullRequest{{Title: "don't worry about it!", Labels: []string{"misc"}}},
 		},
 		{
 			It: "sorts PRs by number",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Number: 1,
 					Labels: []string{"enhancement"},
@@ -82,12 +84,16 @@ func (s *GenerateSuite) TestGenerate() {
 				},
 			},
 
-			ExpectedFeatures: []generate.PullRequest{{Number: 1}, {Number: 2}, {Number: 3}},
+			ExpectedFeatures: []generate.PullRequest{
+				{Number: 1, Labels: []string{"enhancement"}},
+				{Number: 2, Labels: []string{"enhancement"}},
+				{Number: 3, Labels: []string{"enhancement"}},
+			},
 		},
 		{
 			It: "sorts PRs with priority label first",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Number: 1,
 					Labels: []string{"enhancement"},
@@ -102,48 +108,52 @@ func (s *GenerateSuite) TestGenerate() {
 				},
 			},
 
-			ExpectedFeatures: []generate.PullRequest{{Number: 3}, {Number: 1}, {Number: 2}},
+			ExpectedFeatures: []generate.PullRequest{
+				{Number: 3, Labels: []string{"enhancement", "priority"}},
+				{Number: 1, Labels: []string{"enhancement"}},
+				{Number: 2, Labels: []string{"enhancement"}},
+			},
 		},
 		{
 			It: "groups PRs as breaking first",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "new breaking change!",
 					Labels: []string{"enhancement", "breaking", "misc", "bug"},
 				},
 			},
 
-			ExpectedBreaking: []generate.PullRequest{{Title: "new breaking change!"}},
+			ExpectedBreaking: []generate.PullRequest{{Title: "new breaking change!", Labels: []string{"enhancement", "breaking", "misc", "bug"}}},
 		},
 		{
 			It: "groups PRs as misc before bugs and features",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "super fun pull request",
 					Labels: []string{"enhancement", "misc", "bug"},
 				},
 			},
 
-			ExpectedMisc: []generate.PullRequest{{Title: "super fun pull request"}},
+			ExpectedMisc: []generate.PullRequest{{Title: "super fun pull request", Labels: []string{"enhancement", "misc", "bug"}}},
 		},
 		{
 			It: "groups PRs as misc before features",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "best feature ever",
 					Labels: []string{"enhancement", "misc"},
 				},
 			},
 
-			ExpectedMisc: []generate.PullRequest{{Title: "best feature ever"}},
+			ExpectedMisc: []generate.PullRequest{{Title: "best feature ever", Labels: []string{"enhancement", "misc"}}},
 		},
 		{
 			It: "fails when PR does not have appropriate label",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Number: 1,
 					Url:    "http://pr/1",
@@ -167,12 +177,13 @@ func (s *GenerateSuite) TestGenerate() {
 					"http://pr/1",
 					"http://pr/3",
 				},
+				ValidLabels: []string{"breaking", "misc", "bug", "enhancement"},
 			},
 		},
 		{
 			It: "parses pull request release note description from header Release Note",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "Fist of the North Star",
 					Labels: []string{"enhancement"},
@@ -192,6 +203,7 @@ its over`,
 			ExpectedFeatures: []generate.PullRequest{
 				{
 					Title:       "Fist of the North Star",
+					Labels:      []string{"enhancement"},
 					ReleaseNote: "omai wa mo shindeiru",
 				},
 			},
@@ -199,7 +211,7 @@ its over`,
 		{
 			It: "parses pull request release note description from header Release Notes",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "Fist of the North Star",
 					Labels: []string{"enhancement"},
@@ -212,6 +224,7 @@ omai wa mo shindeiru`,
 			ExpectedFeatures: []generate.PullRequest{
 				{
 					Title:       "Fist of the North Star",
+					Labels:      []string{"enhancement"},
 					ReleaseNote: "omai wa mo shindeiru",
 				},
 			},
@@ -219,7 +232,7 @@ omai wa mo shindeiru`,
 		{
 			It: "parses pull request description from header case insensitive",
 
-			PRs: []github.PullRequest{
+			PRs: []provider.PullRequest{
 				{
 					Title:  "Fist of the North Star",
 					Labels: []string{"enhancement"},
@@ -232,24 +245,27 @@ omai wa mo shindeiru`,
 			ExpectedFeatures: []generate.PullRequest{
 				{
 					Title:       "Fist of the North Star",
+					Labels:      []string{"enhancement"},
 					ReleaseNote: "omai wa mo shindeiru",
 				},
 			},
 		},
 	} {
 		s.Run(t.It, func() {
+			release := generate.Release{Version: "1.0.0"}
+
 			fakeTemplate := new(mocks.Template)
-			fakeTemplate.On("Render", mock.Anything).Return(nil)
+			fakeTemplate.On("Render", mock.Anything, mock.Anything).Return(nil)
 
-			generator := generate.New(fakeTemplate)
+			generator := generate.New(fakeTemplate, config.Default)
 
-			err := generator.Generate(t.PRs)
+			err := generator.Generate(release, t.PRs)
 			if t.GenerateErr != nil {
 				s.Equal(err.Error(), t.GenerateErr.Error())
 			} else {
 				s.NoError(err)
 
-				fakeTemplate.AssertCalled(s.T(), "Render", []generate.Section{
+				fakeTemplate.AssertCalled(s.T(), "Render", release, []generate.Section{
 					generate.Section{Title: "Breaking", Icon: "🚨", PRs: t.ExpectedBreaking},
 					generate.Section{Title: "Features", Icon: "✈️", PRs: t.ExpectedFeatures},
 					generate.Section{Title: "Bug Fixes", Icon: "🐞", PRs: t.ExpectedBugFixes},
@@ -259,3 +275,230 @@ omai wa mo shindeiru`,
 		})
 	}
 }
+
+func (s *GenerateSuite) TestGenerateWithConfiguredSections() {
+	cfg := config.Config{
+		Sections: []config.Section{
+			{Title: "New", Icon: "🌱", Labels: []string{"feature", "enhancement"}, Precedence: 2},
+			{Title: "Fixed", Icon: "🔧", Labels: []string{"bug", "regression"}, Precedence: 1},
+		},
+	}
+
+	fakeTemplate := new(mocks.Template)
+	fakeTemplate.On("Render", mock.Anything, mock.Anything).Return(nil)
+
+	err := generate.New(fakeTemplate, cfg).Generate(generate.Release{}, []provider.PullRequest{
+		{Number: 1, Labels: []string{"enhancement"}},
+		{Number: 2, Labels: []string{"feature", "regression"}},
+		{Number: 3, Labels: []string{"bug"}},
+	})
+	s.NoError(err)
+
+	fakeTemplate.AssertCalled(s.T(), "Render", generate.Release{}, []generate.Section{
+		{Title: "New", Icon: "🌱", PRs: []generate.PullRequest{{Number: 1, Labels: []string{"enhancement"}}}},
+		{Title: "Fixed", Icon: "🔧", PRs: []generate.PullRequest{
+			{Number: 2, Labels: []string{"feature", "regression"}},
+			{Number: 3, Labels: []string{"bug"}},
+		}},
+	})
+}
+
+func (s *GenerateSuite) TestGenerateWithClosingIssues() {
+	fakeTemplate := new(mocks.Template)
+	fakeTemplate.On("Render", mock.Anything, mock.Anything).Return(nil)
+
+	err := generate.New(fakeTemplate, config.Default).Generate(generate.Release{}, []provider.PullRequest{
+		{Number: 1, Labels: []string{"bug"}, ClosingIssues: []provider.Issue{{Number: 3, Url: "http://issue/3"}}},
+	})
+	s.NoError(err)
+
+	fakeTemplate.AssertCalled(s.T(), "Render", generate.Release{}, []generate.Section{
+		{Title: "Breaking", Icon: "🚨"},
+		{Title: "Features", Icon: "✈️"},
+		{Title: "Bug Fixes", Icon: "🐞", PRs: []generate.PullRequest{
+			{Number: 1, Labels: []string{"bug"}, Issues: []generate.Issue{{Number: 3, URL: "http://issue/3"}}},
+		}},
+		{Title: "Miscellaneous", Icon: "🤷"},
+	})
+}
+
+func (s *GenerateSuite) TestGenerateWithConfiguredSectionsFailsWithUnknownLabels() {
+	cfg := config.Config{
+		Sections: []config.Section{
+			{Title: "New", Labels: []string{"feature"}},
+		},
+	}
+
+	fakeTemplate := new(mocks.Template)
+
+	err := generate.New(fakeTemplate, cfg).Generate(generate.Release{}, []provider.PullRequest{
+		{Number: 1, Url: "http://pr/1", Labels: []string{"enhancement"}},
+	})
+	s.Equal(generate.PullRequestsNotLabelled{
+		Identifiers: []string{"http://pr/1"},
+		ValidLabels: []string{"feature"},
+	}, err)
+	fakeTemplate.AssertNotCalled(s.T(), "Render", mock.Anything, mock.Anything)
+}
+
+func (s *GenerateSuite) TestValidate() {
+	s.True(generate.Validate(config.Default, []string{"area/web", "bug"}))
+	s.False(generate.Validate(config.Default, []string{"area/web", "priority"}))
+	s.False(generate.Validate(config.Default, nil))
+}
+
+func (s *GenerateSuite) TestValidateReleaseNote() {
+	for _, t := range []struct {
+		It     string
+		Labels []string
+		Body   string
+		Err    error
+	}{
+		{
+			It:     "does not require a release note for other labels",
+			Labels: []string{"bug"},
+			Body:   "# Description\n\nFixes a typo",
+		},
+		{
+			It:     "accepts a release note",
+			Labels: []string{"enhancement"},
+			Body:   "# Description\n\nAdds a flag\n\n## Release Note\n\nAdds the --verbose flag",
+		},
+		{
+			It:     "accepts the opt out",
+			Labels: []string{"breaking"},
+			Body:   "## Release Note\n\nnone\n",
+		},
+		{
+			It:     "requires a release note section",
+			Labels: []string{"area/web", "breaking"},
+			Body:   "# Description\n\nRemoves a flag",
+			Err: generate.ReleaseNoteInvalid{
+				Rule:   generate.RuleReleaseNoteMissing,
+				Label:  "breaking",
+				OptOut: "NONE",
+			},
+		},
+		{
+			It:     "requires the release note section to have content",
+			Labels: []string{"enhancement"},
+			Body:   "# Description\n\nAdds a flag\n\n## Release Note\n\n   \n## Screenshots\n",
+			Err: generate.ReleaseNoteInvalid{
+				Rule:   generate.RuleReleaseNoteEmpty,
+				Label:  "enhancement",
+				OptOut: "NONE",
+			},
+		},
+	} {
+		s.Run(t.It, func() {
+			err := generate.ValidateReleaseNote(config.Default, t.Labels, t.Body)
+			if t.Err == nil {
+				s.NoError(err)
+			} else {
+				s.Equal(t.Err, err)
+			}
+		})
+	}
+}
+
+func (s *GenerateSuite) TestValidateReleaseNoteWithoutOptOut() {
+	cfg := config.Default
+	cfg.ReleaseNote = config.ReleaseNoteRules{RequiredFor: []string{"bug"}}
+
+	s.NoError(generate.ValidateReleaseNote(cfg, []string{"enhancement"}, ""))
+
+	err := generate.ValidateReleaseNote(cfg, []string{"bug"}, "## Release Note\n")
+	s.EqualError(err, `rule release-note-empty failed: pull requests labelled "bug" need a non-empty "## Release Note" section`)
+}
+
+func (s *GenerateSuite) TestReleaseNoteInvalidMessage() {
+	err := generate.ReleaseNoteInvalid{Rule: generate.RuleReleaseNoteMissing, Label: "breaking", OptOut: "NONE"}
+	s.EqualError(err, `rule release-note-missing failed: pull requests labelled "breaking" need a "## Release Note" section, or "NONE" to opt out`)
+}
+
+func (s *GenerateSuite) TestGenerateOmitsOptedOutReleaseNotes() {
+	fakeTemplate := new(mocks.Template)
+	fakeTemplate.On("Render", mock.Anything, mock.Anything).Return(nil)
+
+	err := generate.New(fakeTemplate, config.Default).Generate(generate.Release{}, []provider.PullRequest{
+		{Number: 1, Labels: []string{"enhancement"}, Body: "## Release Note\n\nNONE"},
+	})
+	s.NoError(err)
+
+	fakeTemplate.AssertCalled(s.T(), "Render", generate.Release{}, []generate.Section{
+		{Title: "Breaking", Icon: "🚨"},
+		{Title: "Features", Icon: "✈️", PRs: []generate.PullRequest{{Number: 1, Labels: []string{"enhancement"}}}},
+		{Title: "Bug Fixes", Icon: "🐞"},
+		{Title: "Miscellaneous", Icon: "🤷"},
+	})
+}
+
+func (s *GenerateSuite) TestGenerateWithMultipleTemplates() {
+	releaseNote := new(mocks.Template)
+	releaseNote.On("Render", mock.Anything, mock.Anything).Return(nil)
+	changelog := new(mocks.Template)
+	changelog.On("Render", mock.Anything, mock.Anything).Return(nil)
+
+	err := generate.New(generate.MultiTemplate(releaseNote, changelog), config.Default).Generate(generate.Release{Version: "1.1.0"}, []provider.PullRequest{
+		{Number: 2, Labels: []string{"bug"}},
+		{Number: 1, Labels: []string{"bug"}},
+	})
+	s.NoError(err)
+
+	sections := []generate.Section{
+		{Title: "Breaking", Icon: "🚨"},
+		{Title: "Features", Icon: "✈️"},
+		{Title: "Bug Fixes", Icon: "🐞", PRs: []generate.PullRequest{{Number: 1, Labels: []string{"bug"}}, {Number: 2, Labels: []string{"bug"}}}},
+		{Title: "Miscellaneous", Icon: "🤷"},
+	}
+	releaseNote.AssertCalled(s.T(), "Render", generate.Release{Version: "1.1.0"}, sections)
+	changelog.AssertCalled(s.T(), "Render", generate.Release{Version: "1.1.0"}, sections)
+	releaseNote.AssertNumberOfCalls(s.T(), "Render", 1)
+	changelog.AssertNumberOfCalls(s.T(), "Render", 1)
+}
+
+func (s *GenerateSuite) TestNextBump() {
+	s.Equal(version.BumpPatch, generate.NextBump(config.Default, nil))
+	s.Equal(version.BumpPatch, generate.NextBump(config.Default, []provider.PullRequest{
+		{Labels: []string{"bug"}},
+		{Labels: []string{"misc"}},
+		{Labels: []string{"unknown"}},
+	}))
+	s.Equal(version.BumpMinor, generate.NextBump(config.Default, []provider.PullRequest{
+		{Labels: []string{"bug"}},
+		{Labels: []string{"enhancement"}},
+	}))
+	s.Equal(version.BumpMajor, generate.NextBump(config.Default, []provider.PullRequest{
+		{Labels: []string{"enhancement"}},
+		{Labels: []string{"bug", "breaking"}},
+	}))
+	s.Equal(version.BumpMinor, generate.NextBump(config.Default, []provider.PullRequest{
+		{Labels: []string{"enhancement", "misc"}},
+	}))
+}
+
+func (s *GenerateSuite) TestGenerateWithFallbackSection() {
+	cfg := config.Config{
+		Sections: []config.Section{
+			{Title: "Features", Labels: []string{"enhancement"}},
+			{Title: "Other", Fallback: true},
+		},
+	}
+
+	fakeTemplate := new(mocks.Template)
+	fakeTemplate.On("Render", mock.Anything, mock.Anything).Return(nil)
+
+	err := generate.New(fakeTemplate, cfg).Generate(generate.Release{}, []provider.PullRequest{
+		{Number: 1, Labels: []string{"enhancement"}},
+		{Number: 2},
+		{Number: 3, Labels: []string{"bug"}},
+	})
+	s.NoError(err)
+
+	fakeTemplate.AssertCalled(s.T(), "Render", generate.Release{}, []generate.Section{
+		{Title: "Features", PRs: []generate.PullRequest{{Number: 1, Labels: []string{"enhancement"}}}},
+		{Title: "Other", PRs: []generate.PullRequest{{Number: 2}, {Number: 3, Labels: []string{"bug"}}}},
+	})
+
+	s.True(generate.Validate(cfg, nil))
+}
@@ -0,0 +1,134 @@
+package generate
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the schema of the JSON release note. It
+// is only incremented when a field is removed or changes meaning, never when a
+// field is added.
+const JSONSchemaVersion = 1
+
+type jsonReleaseNote struct {
+	SchemaVersion   int           `json:"schema_version"`
+	Version         string        `json:"version"`
+	PreviousVersion string        `json:"previous_version"`
+	Branch          string        `json:"branch"`
+	Range           jsonRange     `json:"range"`
+	Date            time.Time     `json:"date"`
+	CompareURL      string        `json:"compare_url"`
+	Sections        []jsonSection `json:"sections"`
+
+	// Contributors is omitted unless the release note credits its
+	// contributors
+	Contributors []jsonContributor `json:"contributors,omitempty"`
+}
+
+type jsonContributor struct {
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	FirstTime bool   `json:"first_time"`
+}
+
+type jsonRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type jsonSection struct {
+	Title        string            `json:"title"`
+	Icon         string            `json:"icon"`
+	PullRequests []jsonPullRequest `json:"pull_requests"`
+}
+
+type jsonPullRequest struct {
+	Number      int         `json:"number"`
+	Title       string      `json:"title"`
+	Author      string      `json:"author"`
+	URL         string      `json:"url"`
+	Labels      []string    `json:"labels"`
+	ReleaseNote string      `json:"release_note"`
+	Issues      []jsonIssue `json:"issues"`
+}
+
+type jsonIssue struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// JSONTemplater renders the release note as a JSON document for other tools
+// to consume, instead of using a go template.
+type JSONTemplater struct {
+	w io.Writer
+}
+
+func NewJSONTemplater(w io.Writer) *JSONTemplater {
+	return &JSONTemplater{w: w}
+}
+
+// Render writes every section, including empty ones, so that consumers can
+// rely on the sections of the config always being present. Lists are never
+// null.
+func (j *JSONTemplater) Render(release Release, sections []Section) error {
+	releaseNote := jsonReleaseNote{
+		SchemaVersion:   JSONSchemaVersion,
+		Version:         release.Version,
+		PreviousVersion: release.PreviousVersion,
+		Branch:          release.Branch,
+		Range: jsonRange{
+			From: release.From,
+			To:   release.To,
+		},
+		Date:       release.Date,
+		CompareURL: release.CompareURL,
+		Sections:   []jsonSection{},
+	}
+
+	for _, section := range sections {
+		prs := []jsonPullRequest{}
+		for _, pr := range section.PRs {
+			labels := pr.Labels
+			if labels == nil {
+				labels = []string{}
+			}
+
+			issues := []jsonIssue{}
+			for _, issue := range pr.Issues {
+				issues = append(issues, jsonIssue{
+					Number: issue.Number,
+					URL:    issue.URL,
+				})
+			}
+
+			prs = append(prs, jsonPullRequest{
+				Number:      pr.Number,
+				Title:       pr.Title,
+				Author:      pr.Author,
+				URL:         pr.URL,
+				Labels:      labels,
+				ReleaseNote: pr.ReleaseNote,
+				Issues:      issues,
+			})
+		}
+
+		releaseNote.Sections = append(releaseNote.Sections, jsonSection{
+			Title:        section.Title,
+			Icon:         section.Icon,
+			PullRequests: prs,
+		})
+	}
+
+	for _, contributor := range release.Contributors {
+		releaseNote.Contributors = append(releaseNote.Contributors, jsonContributor{
+			Login:     contributor.Login,
+			Name:      contributor.Name,
+			FirstTime: contributor.FirstTime,
+		})
+	}
+
+	encoder := json.NewEncoder(j.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(releaseNote)
+}
@@ -0,0 +1,90 @@
+package generate_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/generate"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestJSON(t *testing.T) {
+	suite.Run(t, &JSONSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type JSONSuite struct {
+	suite.Suite
+	*require.Assertions
+}
+
+func (s *JSONSuite) TestRender() {
+	buf := new(bytes.Buffer)
+	err := generate.NewJSONTemplater(buf).Render(generate.Release{
+		Version:         "1.1.0",
+		PreviousVersion: "1.0.0",
+		Branch:          "master",
+		From:            "abc123",
+		To:              "def456",
+		Date:            time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+		CompareURL:      "https://github.com/clarafu/release-me/compare/1.0.0...1.1.0",
+	}, []generate.Section{
+		{
+			Title: "Features",
+			Icon:  "✈️",
+			PRs: []generate.PullRequest{
+				{
+					Title:       "PR Title",
+					Number:      12,
+					Author:      "clarafu",
+					URL:         "https://github.com/clarafu/release-me/pull/12",
+					Labels:      []string{"enhancement", "priority"},
+					ReleaseNote: "something new",
+					Issues:      []generate.Issue{{Number: 3, URL: "https://github.com/clarafu/release-me/issues/3"}},
+				},
+				{Title: "Unlabelled", Number: 13},
+			},
+		},
+		{Title: "Bug Fixes", Icon: "🐞"},
+	})
+	s.NoError(err)
+	s.JSONEq(`{
+  "schema_version": 1,
+  "version": "1.1.0",
+  "previous_version": "1.0.0",
+  "branch": "master",
+  "range": {"from": "abc123", "to": "def456"},
+  "date": "2020-06-01T00:00:00Z",
+  "compare_url": "https://github.com/clarafu/release-me/compare/1.0.0...1.1.0",
+  "sections": [
+    {
+      "title": "Features",
+      "icon": "✈️",
+      "pull_requests": [
+        {
+          "number": 12,
+          "title": "PR Title",
+          "author": "clarafu",
+          "url": "https://github.com/clarafu/release-me/pull/12",
+          "labels": ["enhancement", "priority"],
+          "release_note": "something new",
+          "issues": [{"number": 3, "url": "https://github.com/clarafu/release-me/issues/3"}]
+        },
+        {
+          "number": 13,
+          "title": "Unlabelled",
+          "author": "",
+          "url": "",
+          "labels": [],
+          "release_note": "",
+          "issues": []
+        }
+      ]
+    },
+    {"title": "Bug Fixes", "icon": "🐞", "pull_requests": []}
+  ]
+}`, buf.String())
+}
@@ -12,13 +12,13 @@ type Template struct {
 	mock.Mock
 }
 
-// Render provides a mock function with given fields: sections
-func (_m *Template) Render(sections []generate.Section) error {
-	ret := _m.Called(sections)
+// Render provides a mock function with given fields: release, sections
+func (_m *Template) Render(release generate.Release, sections []generate.Section) error {
+	ret := _m.Called(release, sections)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]generate.Section) error); ok {
-		r0 = rf(sections)
+	if rf, ok := ret.Get(0).(func(generate.Release, []generate.Section) error); ok {
+		r0 = rf(release, sections)
 	} else {
 		r0 = ret.Error(0)
 	}
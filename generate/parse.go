@@ -35,9 +35,17 @@ var releaseNoteRegexp = regexp.MustCompile(regen.Sequence(
 ).Group().NoCapture().SetFlags(regen.FlagMultiLine).Regexp())
 
 func parseReleaseNote(body string) string {
+	releaseNote, _ := findReleaseNote(body)
+	return releaseNote
+}
+
+// findReleaseNote returns the contents of the release note section of the
+// body, and whether the body has a release note section at all so that a
+// missing section can be told apart from an empty one.
+func findReleaseNote(body string) (string, bool) {
 	groups := releaseNoteRegexp.FindStringSubmatch(body)
 	if len(groups) < 2 {
-		return ""
+		return "", false
 	}
-	return groups[1]
+	return groups[1], true
 }
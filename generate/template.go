@@ -1,16 +1,52 @@
 package generate
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 )
 
+// Release is the metadata of the release that the release note is generated
+// for.
+type Release struct {
+	Version         string
+	PreviousVersion string
+	Date            time.Time
+	CompareURL      string
+
+	// Branch is the branch the pull requests were merged into
+	Branch string
+	// From and To are the commits of the range of history the pull requests
+	// were read from. From is the commit of the previous release, excluding
+	// the pull requests merged at it, and is empty if no previous release was
+	// found. To is the commit the range ends at, including its pull requests.
+	From string
+	To   string
+
+	// Contributors are only set when the release note credits its
+	// contributors
+	Contributors []Contributor
+}
+
 type PullRequest struct {
 	Title       string
 	Number      int
 	Author      string
+	URL         string
+	Labels      []string
 	ReleaseNote string
+
+	// Issues are the issues that the pull request closed
+	Issues []Issue
+}
+
+type Issue struct {
+	Number int
+	URL    string
 }
 
 type Section struct {
@@ -31,33 +67,92 @@ const rawTemplate = `
 ## {{$section.Icon}} {{$section.Title}}
 
 {{ range $pr := $section.PRs }}
-* {{$pr.Title}} (#{{$pr.Number}}) @{{$pr.Author}} <sub><sup><a name="{{$pr.Number}}" href="#{{$pr.Number}}">:link:</a></sup></sub>  
+* {{$pr.Title}} (#{{$pr.Number}}) @{{$pr.Author}}{{if $pr.Issues}} fixes {{range $i, $issue := $pr.Issues}}{{if $i}}, {{end}}[#{{$issue.Number}}]({{$issue.URL}}){{end}}{{end}} <sub><sup><a name="{{$pr.Number}}" href="#{{$pr.Number}}">:link:</a></sup></sub>  
 {{ $pr.ReleaseNote | indent 2 }}
 {{end}}
 {{end}}
 {{end}}
+{{- if .Contributors}}
+
+## 🙏 Contributors
+
+Thank you to everyone who contributed to this release!
+
+{{range .Contributors -}}
+* {{if .Login}}@{{.Login}}{{else}}{{.Name}}{{end}}{{if .FirstTime}} 🎉 made their first contribution{{end}}
+{{end}}
+{{- end}}
 `
 
+func date(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+func defaultValue(def string, v string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
 var funcMap = template.FuncMap{
-	"indent": indent,
+	"indent":     indent,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      strings.Title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, v string) string { return strings.TrimPrefix(v, prefix) },
+	"trimSuffix": func(suffix, v string) string { return strings.TrimSuffix(v, suffix) },
+	"replace":    func(old, new, v string) string { return strings.Replace(v, old, new, -1) },
+	"contains":   func(substr, v string) bool { return strings.Contains(v, substr) },
+	"hasPrefix":  func(prefix, v string) bool { return strings.HasPrefix(v, prefix) },
+	"hasSuffix":  func(suffix, v string) bool { return strings.HasSuffix(v, suffix) },
+	"join":       func(sep string, v []string) string { return strings.Join(v, sep) },
+	"split":      func(sep, v string) []string { return strings.Split(v, sep) },
+	"date":       date,
+	"default":    defaultValue,
 }
 
 var releaseNotesTemplate = template.Must(template.New("release_notes").Funcs(funcMap).Parse(rawTemplate))
 
 type ReleaseNoteTemplater struct {
-	w io.Writer
+	w        io.Writer
+	template *template.Template
 }
 
 func NewReleaseNoteTemplater(w io.Writer) *ReleaseNoteTemplater {
 	return &ReleaseNoteTemplater{
-		w: w,
+		w:        w,
+		template: releaseNotesTemplate,
 	}
 }
 
-func (r *ReleaseNoteTemplater) Render(sections []Section) error {
-	return releaseNotesTemplate.Execute(r.w, struct {
+// NewReleaseNoteTemplaterFromFile uses the go template within the file at
+// the given path to render the release note, instead of the default
+// template.
+func NewReleaseNoteTemplaterFromFile(w io.Writer, path string) (*ReleaseNoteTemplater, error) {
+	rawFileTemplate, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	fileTemplate, err := template.New(filepath.Base(path)).Funcs(funcMap).Parse(string(rawFileTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &ReleaseNoteTemplater{
+		w:        w,
+		template: fileTemplate,
+	}, nil
+}
+
+func (r *ReleaseNoteTemplater) Render(release Release, sections []Section) error {
+	return r.template.Execute(r.w, struct {
+		Release
 		Sections []Section
 	}{
+		Release:  release,
 		Sections: sections,
 	})
 }
@@ -2,7 +2,11 @@ package generate_test
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/clarafu/release-me/generate"
 	"github.com/stretchr/testify/require"
@@ -46,7 +50,7 @@ func (s *TemplateSuite) TestRemovesEmptySections() {
 		},
 	}
 	buf := new(bytes.Buffer)
-	generate.NewReleaseNoteTemplater(buf).Render(sections)
+	generate.NewReleaseNoteTemplater(buf).Render(generate.Release{}, sections)
 	s.NotContains(buf.String(), "no PRs")
 }
 
@@ -76,7 +80,108 @@ func (s *TemplateSuite) TestMultipleSections() {
 		},
 	}
 	buf := new(bytes.Buffer)
-	generate.NewReleaseNoteTemplater(buf).Render(sections)
+	generate.NewReleaseNoteTemplater(buf).Render(generate.Release{}, sections)
 	s.Contains(buf.String(), "Section 1")
 	s.Contains(buf.String(), "Section 2")
 }
+
+func (s *TemplateSuite) TestLinksClosedIssues() {
+	buf := new(bytes.Buffer)
+	err := generate.NewReleaseNoteTemplater(buf).Render(generate.Release{}, []generate.Section{
+		{
+			Title: "Bug Fixes",
+			PRs: []generate.PullRequest{
+				{
+					Title:  "Fix a bug",
+					Number: 12,
+					Author: "alice",
+					Issues: []generate.Issue{
+						{Number: 3, URL: "https://github.com/clarafu/release-me/issues/3"},
+						{Number: 7, URL: "https://github.com/clarafu/release-me/issues/7"},
+					},
+				},
+				{Title: "Fix another bug", Number: 13, Author: "bob"},
+			},
+		},
+	})
+	s.NoError(err)
+	s.Contains(buf.String(), "* Fix a bug (#12) @alice fixes [#3](https://github.com/clarafu/release-me/issues/3), [#7](https://github.com/clarafu/release-me/issues/7) <sub>")
+	s.Contains(buf.String(), "* Fix another bug (#13) @bob <sub>")
+}
+
+func (s *TemplateSuite) TestContributors() {
+	buf := new(bytes.Buffer)
+	err := generate.NewReleaseNoteTemplater(buf).Render(generate.Release{
+		Contributors: []generate.Contributor{
+			{Login: "alice"},
+			{Name: "Bob Smith", FirstTime: true},
+			{Login: "carol", FirstTime: true},
+		},
+	}, nil)
+	s.NoError(err)
+	s.Contains(buf.String(), `## 🙏 Contributors
+
+Thank you to everyone who contributed to this release!
+
+* @alice
+* Bob Smith 🎉 made their first contribution
+* @carol 🎉 made their first contribution
+`)
+}
+
+func (s *TemplateSuite) TestNoContributorsSection() {
+	buf := new(bytes.Buffer)
+	err := generate.NewReleaseNoteTemplater(buf).Render(generate.Release{}, nil)
+	s.NoError(err)
+	s.NotContains(buf.String(), "Contributors")
+}
+
+func (s *TemplateSuite) TestTemplateFromFile() {
+	dir, err := ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notes.tmpl")
+	err = ioutil.WriteFile(path, []byte(`# {{.Version}} ({{date "2006-01-02" .Date}})
+{{range .Sections}}{{if .PRs}}
+### {{upper .Title}}
+{{range .PRs}}- {{.Title}} by @{{.Author}}
+{{end}}{{end}}{{end}}
+Compare {{default "the beginning" .PreviousVersion}}: {{.CompareURL}}
+`), 0644)
+	s.NoError(err)
+
+	buf := new(bytes.Buffer)
+	templater, err := generate.NewReleaseNoteTemplaterFromFile(buf, path)
+	s.NoError(err)
+
+	err = templater.Render(generate.Release{
+		Version:         "1.1.0",
+		PreviousVersion: "1.0.0",
+		Date:            time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+		CompareURL:      "https://github.com/clarafu/release-me/compare/1.0.0...1.1.0",
+	}, []generate.Section{
+		{Title: "Features", PRs: []generate.PullRequest{{Title: "PR Title", Author: "clarafu"}}},
+		{Title: "Bug Fixes"},
+	})
+	s.NoError(err)
+	s.Equal(`# 1.1.0 (2020-06-01)
+
+### FEATURES
+- PR Title by @clarafu
+
+Compare 1.0.0: https://github.com/clarafu/release-me/compare/1.0.0...1.1.0
+`, buf.String())
+}
+
+func (s *TemplateSuite) TestTemplateFromFileFailsToParse() {
+	dir, err := ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notes.tmpl")
+	s.NoError(ioutil.WriteFile(path, []byte(`{{.Version`), 0644))
+
+	_, err = generate.NewReleaseNoteTemplaterFromFile(new(bytes.Buffer), path)
+	s.Error(err)
+}
@@ -0,0 +1,159 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// App is a GitHub App installation to authenticate as, instead of using a
+// token.
+type App struct {
+	ID             int64
+	InstallationID int64
+
+	// PrivateKey is the PEM encoded private key of the app
+	PrivateKey []byte
+}
+
+// installationTokenSource exchanges a JWT signed by the app for an
+// installation access token. Installation tokens expire after an hour, so
+// it is wrapped in a oauth2.ReuseTokenSource to mint a new token when the
+// current one expires.
+type installationTokenSource struct {
+	app        App
+	key        *rsa.PrivateKey
+	apiURL     string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func newInstallationTokenSource(app App, apiURL string, httpClient *http.Client) (oauth2.TokenSource, error) {
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app private key: %w", err)
+	}
+
+	return oauth2.ReuseTokenSource(nil, &installationTokenSource{
+		app:        app,
+		key:        key,
+		apiURL:     apiURL,
+		httpClient: httpClient,
+		now:        time.Now,
+	}), nil
+}
+
+func parsePrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	// GitHub generates PKCS #1 keys, but keys that were converted to PKCS #8
+	// are also accepted
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign github app jwt: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", s.apiURL, s.app.InstallationID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create github app installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create github app installation token: unexpected status %s: %s", resp.Status, body)
+	}
+
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&installationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode github app installation token: %w", err)
+	}
+
+	// Refresh the token a minute early so that it does not expire in the
+	// middle of a paginated query
+	return &oauth2.Token{
+		AccessToken: installationToken.Token,
+		Expiry:      installationToken.ExpiresAt.Add(-time.Minute),
+	}, nil
+}
+
+// jwt returns a JSON Web Token signed by the private key of the app, which
+// authenticates as the app itself.
+func (s *installationTokenSource) jwt() (string, error) {
+	now := s.now()
+
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The issued at time is set in the past to allow for clock drift, and
+	// GitHub does not accept tokens that expire more than 10 minutes later
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.app.ID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
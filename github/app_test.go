@@ -0,0 +1,139 @@
+package github_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/github"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestApp(t *testing.T) {
+	suite.Run(t, &AppSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type AppSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	tokensMinted  int
+	authorization []string
+}
+
+func (s *AppSuite) SetupTest() {
+	var err error
+	s.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	s.NoError(err)
+
+	s.tokensMinted = 0
+	s.authorization = nil
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPost, r.Method)
+		s.verifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+		// The token expires immediately so that every request mints a new one
+		s.tokensMinted++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("installation-token-%d", s.tokensMinted),
+			"expires_at": time.Now().Add(time.Minute),
+		})
+	})
+	mux.HandleFunc("/api/v3/repos/some-owner/some-repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		s.authorization = append(s.authorization, r.Header.Get("Authorization"))
+		w.Write([]byte(`[]`))
+	})
+
+	s.server = httptest.NewServer(mux)
+}
+
+func (s *AppSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *AppSuite) verifyJWT(jwt string) {
+	parts := strings.Split(jwt, ".")
+	s.Len(parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	s.NoError(err)
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	s.NoError(rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, hash[:], signature))
+
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	s.NoError(err)
+
+	var claims struct {
+		IAT int64  `json:"iat"`
+		EXP int64  `json:"exp"`
+		ISS string `json:"iss"`
+	}
+	s.NoError(json.Unmarshal(rawClaims, &claims))
+	s.Equal("7", claims.ISS)
+	s.True(claims.IAT < time.Now().Unix())
+	s.True(claims.EXP-claims.IAT <= 600)
+}
+
+func (s *AppSuite) TestAuthenticatesAsInstallation() {
+	client, err := github.New("", github.Options{
+		APIURL: s.server.URL + "/api/v3",
+		App: &github.App{
+			ID:             7,
+			InstallationID: 42,
+			PrivateKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(s.key),
+			}),
+		},
+	})
+	s.NoError(err)
+
+	_, err = client.FetchReleaseByTag("some-owner", "some-repo", "v1.0.0")
+	s.NoError(err)
+	_, err = client.FetchReleaseByTag("some-owner", "some-repo", "v1.0.0")
+	s.NoError(err)
+
+	s.Equal([]string{"Bearer installation-token-1", "Bearer installation-token-2"}, s.authorization)
+}
+
+func (s *AppSuite) TestAcceptsPKCS8Keys() {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(s.key)
+	s.NoError(err)
+
+	_, err = github.New("", github.Options{
+		App: &github.App{
+			ID:             7,
+			InstallationID: 42,
+			PrivateKey:     pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		},
+	})
+	s.NoError(err)
+}
+
+func (s *AppSuite) TestFailsWithInvalidKey() {
+	_, err := github.New("", github.Options{
+		App: &github.App{ID: 7, InstallationID: 42, PrivateKey: []byte("not a key")},
+	})
+	s.Error(err)
+}
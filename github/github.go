@@ -2,46 +2,131 @@ package github
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
 )
 
 type GitHub struct {
-	client *githubv4.Client
+	client     *githubv4.Client
+	httpClient *http.Client
+	apiURL     string
+	limiter    *rateLimiter
 }
 
-type PullRequest struct {
-	ID     string
-	Number int
-	Title  string
-	Body   string
-	Author string
-	Labels []string
-	Merged bool
-	Url    string
+// DefaultAPIURL is the url of the REST API of github.com
+const DefaultAPIURL = "https://api.github.com"
+
+type Options struct {
+	// APIURL is the url of the REST API, which is used to find the GraphQL
+	// API. For GitHub Enterprise Server, this is
+	// https://[hostname]/api/v3. Defaults to the API of github.com.
+	APIURL string
+
+	// CABundle is the path to a file of PEM encoded certificates that are
+	// trusted on top of the system certificates.
+	CABundle string
+
+	// Proxy is the url of the proxy that requests are sent through. If
+	// empty, the proxy is read from the HTTPS_PROXY environment variable.
+	Proxy string
+
+	// App authenticates as an installation of a GitHub App. If set, the
+	// token is not used.
+	App *App
 }
 
-func (pr PullRequest) HasLabel(label string) bool {
-	for _, lbl := range pr.Labels {
-		if lbl == label {
-			return true
-		}
+func New(token string, opts Options) (GitHub, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return GitHub{}, err
 	}
-	return false
-}
 
-func New(token string) GitHub {
+	apiURL := strings.TrimSuffix(opts.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	baseClient := &http.Client{Transport: newRetryTransport(transport)}
+
 	src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	httpClient := oauth2.NewClient(context.Background(), src)
+	if opts.App != nil {
+		src, err = newInstallationTokenSource(*opts.App, apiURL, baseClient)
+		if err != nil {
+			return GitHub{}, err
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, baseClient)
+	httpClient := oauth2.NewClient(ctx, src)
+
 	return GitHub{
-		client: githubv4.NewClient(httpClient),
+		client:     githubv4.NewEnterpriseClient(graphQLURL(apiURL), httpClient),
+		httpClient: httpClient,
+		apiURL:     apiURL,
+		limiter:    &rateLimiter{},
+	}, nil
+}
+
+// graphQLURL returns the url of the GraphQL API that is served alongside the
+// REST API. GitHub Enterprise Server serves the REST API at /api/v3 and the
+// GraphQL API at /api/graphql, while github.com serves the GraphQL API at
+// /graphql of the REST API.
+func graphQLURL(apiURL string) string {
+	if strings.HasSuffix(apiURL, "/api/v3") {
+		return strings.TrimSuffix(apiURL, "/v3") + "/graphql"
+	}
+	return apiURL + "/graphql"
+}
+
+func newTransport(opts Options) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		bundle, err := ioutil.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %s", opts.CABundle)
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
 	}
+
+	return transport, nil
 }
 
+// FetchCommitsFromReleases pages through every release of the repository,
+// so that releases made from older maintenance branches are found even when
+// many newer releases were made since.
 func (g GitHub) FetchCommitsFromReleases(owner, repo string) (map[string]string, error) {
 	var releaseSHAsQuery struct {
 		Repository struct {
@@ -49,52 +134,179 @@ func (g GitHub) FetchCommitsFromReleases(owner, repo string) (map[string]string,
 				Nodes []struct {
 					Tag struct {
 						Name   string
-						Target struct {
-							Oid string
-						}
+						Target tagTarget
 					}
 				}
-			} `graphql:"releases(first: 50, orderBy: {direction: DESC, field: CREATED_AT})"`
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"releases(first: 100, after: $releaseCursor, orderBy: {direction: DESC, field: CREATED_AT})"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
 	}
 
 	releaseSHAsVariables := map[string]interface{}{
-		"owner": githubv4.String(owner),
-		"name":  githubv4.String(repo),
+		"owner":         githubv4.String(owner),
+		"name":          githubv4.String(repo),
+		"releaseCursor": (*githubv4.String)(nil),
+	}
+
+	releaseSHAs := map[string]string{}
+	for {
+		err := g.query(&releaseSHAsQuery, releaseSHAsVariables)
+		if err != nil {
+			return nil, err
+		}
+
+		releases := releaseSHAsQuery.Repository.Releases
+		for _, release := range releases.Nodes {
+			releaseSHAs[release.Tag.Target.commitSHA()] = release.Tag.Name
+		}
+
+		if !releases.PageInfo.HasNextPage {
+			return releaseSHAs, nil
+		}
+
+		releaseSHAsVariables["releaseCursor"] = releases.PageInfo.EndCursor
 	}
+}
 
-	err := g.client.Query(context.Background(), &releaseSHAsQuery, releaseSHAsVariables)
+// tagTarget is the object a tag points to. Lightweight tags point directly to
+// a commit, while annotated tags point to a tag object which points to the
+// commit.
+type tagTarget struct {
+	Oid string
+	Tag struct {
+		Target struct {
+			Oid string
+		}
+	} `graphql:"... on Tag"`
+}
+
+func (t tagTarget) commitSHA() string {
+	if t.Tag.Target.Oid != "" {
+		return t.Tag.Target.Oid
+	}
+	return t.Oid
+}
+
+// FetchCommitsFromTags returns the commit of every tag in the repository,
+// whether or not a release was created for it.
+func (g GitHub) FetchCommitsFromTags(owner, repo string) (map[string]string, error) {
+	var tagsQuery struct {
+		Repository struct {
+			Refs struct {
+				Nodes []struct {
+					Name   string
+					Target tagTarget
+				}
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"refs(refPrefix: \"refs/tags/\", first: 100, after: $tagCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
+	}
+
+	tagsVariables := map[string]interface{}{
+		"owner":     githubv4.String(owner),
+		"name":      githubv4.String(repo),
+		"tagCursor": (*githubv4.String)(nil),
+	}
+
+	tagSHAs := map[string]string{}
+	for {
+		err := g.query(&tagsQuery, tagsVariables)
+		if err != nil {
+			return nil, err
+		}
+
+		refs := tagsQuery.Repository.Refs
+		for _, tag := range refs.Nodes {
+			tagSHAs[tag.Target.commitSHA()] = tag.Name
+		}
+
+		if !refs.PageInfo.HasNextPage {
+			return tagSHAs, nil
+		}
+
+		tagsVariables["tagCursor"] = refs.PageInfo.EndCursor
+	}
+}
+
+// ResolveRevision returns the SHA of the commit that the tag, branch or commit
+// SHA points to, dereferencing annotated tags.
+func (g GitHub) ResolveRevision(owner, repo, revision string) (string, error) {
+	var revisionQuery struct {
+		Repository struct {
+			Object *tagTarget `graphql:"object(expression: $revision)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
+	}
+
+	err := g.query(&revisionQuery, map[string]interface{}{
+		"owner":    githubv4.String(owner),
+		"name":     githubv4.String(repo),
+		"revision": githubv4.String(revision),
+	})
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	releaseSHAs := map[string]string{}
-	for _, release := range releaseSHAsQuery.Repository.Releases.Nodes {
-		releaseSHAs[release.Tag.Target.Oid] = release.Tag.Name
+	if revisionQuery.Repository.Object == nil {
+		return "", fmt.Errorf("revision %s does not exist in %s/%s", revision, owner, repo)
 	}
 
-	return releaseSHAs, nil
+	return revisionQuery.Repository.Object.commitSHA(), nil
 }
 
 func (g GitHub) FetchLatestReleaseCommitFromBranch(owner, repo, branch, versionToRelease string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, lastCommit, err := g.findReleaseCommit(owner, repo, branch, releaseSHAs, provider.PreviousReleaseFilter(versionToRelease))
+	if err != nil {
+		return "", err
+	}
+
+	if releaseCommit == "" {
+		return lastCommit, nil
+	}
+
+	return releaseCommit, nil
+}
+
+// FetchMostRecentReleaseCommitFromBranch returns the commit of the most recent
+// release on the branch, regardless of whether it is a patch release. An
+// empty string is returned if the branch does not contain any release.
+func (g GitHub) FetchMostRecentReleaseCommitFromBranch(owner, repo, branch string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, _, err := g.findReleaseCommit(owner, repo, branch, releaseSHAs, func(string) bool {
+		return true
+	})
+	return releaseCommit, err
+}
+
+// findReleaseCommit walks backwards from the latest commit on the branch and
+// returns the first commit that is associated to a release accepted by
+// isPrevious. If no release is found, the release commit is empty and the
+// oldest commit on the branch is returned as the last commit.
+func (g GitHub) findReleaseCommit(owner, repo, branch string, releaseSHAs map[string]string, isPrevious func(release string) bool) (string, string, error) {
 	var commitsQuery struct {
 		Repository struct {
-			Ref struct {
-				Target struct {
-					Commit struct {
-						History struct {
-							Nodes []struct {
-								Oid string
-							}
-							PageInfo struct {
-								EndCursor   githubv4.String
-								HasNextPage bool
-							}
-						} `graphql:"history(first: 100, after: $commitCursor)"`
-					} `graphql:"... on Commit"`
-				}
-			} `graphql:"ref(qualifiedName: $branch)"`
+			Object struct {
+				Commit struct {
+					History struct {
+						Nodes []struct {
+							Oid string
+						}
+						PageInfo struct {
+							EndCursor   githubv4.String
+							HasNextPage bool
+						}
+					} `graphql:"history(first: 100, after: $commitCursor)"`
+				} `graphql:"... on Commit"`
+			} `graphql:"object(expression: $branch)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
 	}
 
 	commitsVariables := map[string]interface{}{
@@ -105,76 +317,53 @@ func (g GitHub) FetchLatestReleaseCommitFromBranch(owner, repo, branch, versionT
 	}
 
 	var lastCommit string
+	var scanned int
 	for {
-		err := g.client.Query(context.Background(), &commitsQuery, commitsVariables)
+		err := g.query(&commitsQuery, commitsVariables)
 		if err != nil {
-			return "", fmt.Errorf("failed to fetch commits from github: %w", err)
+			return "", "", fmt.Errorf("failed to fetch commits from github: %w", err)
 		}
 
-		history := commitsQuery.Repository.Ref.Target.Commit.History
+		history := commitsQuery.Repository.Object.Commit.History
 		for _, commit := range history.Nodes {
 			lastCommit = commit.Oid
+			scanned++
 
-			// If release-version is a Patch then don't skip patch releases
-			if previousRelease, found := releaseSHAs[commit.Oid]; found {
-				if isPatchRelease(versionToRelease) {
-					return commit.Oid, nil
-				} else { //Major/Minor skip patch releases
-					if !isPatchRelease(previousRelease) {
-						return commit.Oid, nil
-					}
-				}
+			if previousRelease, found := releaseSHAs[commit.Oid]; found && isPrevious(previousRelease) {
+				logger.Debugf("scanned %d commits of %s to find release %s", scanned, branch, previousRelease)
+				return commit.Oid, lastCommit, nil
 			}
 		}
 
 		if !history.PageInfo.HasNextPage {
-			fmt.Printf("could not find a commit from the latest release, generating release note using all commits in branch %s\n", branch)
-			break
+			logger.Debugf("scanned all %d commits of %s without finding a release", scanned, branch)
+			return "", lastCommit, nil
 		}
 
 		commitsVariables["commitCursor"] = history.PageInfo.EndCursor
 	}
-
-	return lastCommit, nil
 }
 
-func (g GitHub) FetchPullRequestsAfterCommit(owner, repo, branch, startingCommitSHA, lastCommitSHA string, ignoreAuthors []string) ([]PullRequest, error) {
+func (g GitHub) FetchPullRequestsAfterCommit(owner, repo, branch, startingCommitSHA, lastCommitSHA string, ignoreAuthors []string) ([]provider.PullRequest, error) {
 	var pullRequestsQuery struct {
 		Repository struct {
-			Ref struct {
-				Target struct {
-					Commit struct {
-						History struct {
-							Nodes []struct {
-								Oid                    string
-								AssociatedPullRequests struct {
-									Nodes []struct {
-										ID     string
-										Title  string
-										Body   string
-										Author struct {
-											Login string
-										}
-										Labels struct {
-											Nodes []struct {
-												Name string
-											}
-										} `graphql:"labels(first: 10)"`
-										Number int
-										Merged bool
-										Url    githubv4.URI
-									}
-								} `graphql:"associatedPullRequests(first: 5)"`
-							}
-							PageInfo struct {
-								EndCursor   githubv4.String
-								HasNextPage bool
-							}
-						} `graphql:"history(first: 100, after: $commitCursor)"`
-					} `graphql:"... on Commit"`
-				}
-			} `graphql:"ref(qualifiedName: $branch)"`
+			Object struct {
+				Commit struct {
+					History struct {
+						Nodes []struct {
+							Oid                    string
+							Message                string
+							AssociatedPullRequests associatedPullRequests `graphql:"associatedPullRequests(first: 5)"`
+						}
+						PageInfo struct {
+							EndCursor   githubv4.String
+							HasNextPage bool
+						}
+					} `graphql:"history(first: 100, after: $commitCursor)"`
+				} `graphql:"... on Commit"`
+			} `graphql:"object(expression: $branch)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
 	}
 
 	pullRequestsVariables := map[string]interface{}{
@@ -185,88 +374,159 @@ func (g GitHub) FetchPullRequestsAfterCommit(owner, repo, branch, startingCommit
 	}
 
 	var appendCommits bool
-	pullRequests := []PullRequest{}
+	pullRequests := []provider.PullRequest{}
 	seen := make(map[string]bool)
 
+	// Co-authors are credited in the commits of a pull request, which follow
+	// the merge commit in the history when the pull request is not squashed
+	indexes := make(map[string]int)
+
 	filteredAuthors := make(map[string]struct{})
 	for _, username := range ignoreAuthors {
 		filteredAuthors[username] = struct{}{}
 	}
 
+	var scanned int
 	for {
-		err := g.client.Query(context.Background(), &pullRequestsQuery, pullRequestsVariables)
+		err := g.query(&pullRequestsQuery, pullRequestsVariables)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch pull requests from github: %w", err)
 		}
 
-		for _, commit := range pullRequestsQuery.Repository.Ref.Target.Commit.History.Nodes {
+		for _, commit := range pullRequestsQuery.Repository.Object.Commit.History.Nodes {
 			if commit.Oid == startingCommitSHA {
+				logger.Debugf("scanned %d commits of %s for pull requests", scanned, branch)
 				return pullRequests, nil
 			}
 
+			scanned++
+
 			if lastCommitSHA == "" || commit.Oid == lastCommitSHA {
 				appendCommits = true
 			}
 
-			for _, pr := range commit.AssociatedPullRequests.Nodes {
+			associated, err := g.allAssociatedPullRequests(owner, repo, commit.Oid, commit.AssociatedPullRequests)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, pr := range associated {
 				if !pr.Merged {
+					logger.Debugf("excluding pull request #%d of commit %s: not merged", pr.Number, commit.Oid)
 					continue
 				}
 
 				if _, exists := seen[pr.ID]; exists {
+					logger.Debugf("excluding pull request #%d of commit %s: duplicate of an earlier commit", pr.Number, commit.Oid)
+					if i, appended := indexes[pr.ID]; appended {
+						pullRequests[i].AddCoAuthors(provider.ParseCoAuthors(commit.Message)...)
+					}
 					continue
 				}
 
 				if _, found := filteredAuthors[pr.Author.Login]; found {
+					logger.Debugf("excluding pull request #%d: author %s is ignored", pr.Number, pr.Author.Login)
 					continue
 				}
 
 				seen[pr.ID] = true
 
-				if appendCommits {
-					labels := make([]string, len(pr.Labels.Nodes))
-					for i, l := range pr.Labels.Nodes {
-						labels[i] = l.Name
-					}
-
-					url, err := pr.Url.MarshalJSON()
-					if err != nil {
-						return nil, fmt.Errorf("failed to format url: %w", err)
-					}
+				if !appendCommits {
+					logger.Debugf("excluding pull request #%d: merged after the last commit %s", pr.Number, lastCommitSHA)
+					continue
+				}
 
-					pullRequests = append(pullRequests, PullRequest{
-						ID:     pr.ID,
-						Number: pr.Number,
-						Title:  pr.Title,
-						Body:   pr.Body,
-						Author: pr.Author.Login,
-						Labels: labels,
-						Merged: pr.Merged,
-						Url:    string(url),
-					})
+				labels, err := g.allLabels(owner, repo, pr)
+				if err != nil {
+					return nil, err
 				}
+
+				indexes[pr.ID] = len(pullRequests)
+				pullRequests = append(pullRequests, provider.PullRequest{
+					ID:        pr.ID,
+					Number:    pr.Number,
+					Title:     pr.Title,
+					Body:      pr.Body,
+					Author:    pr.Author.Login,
+					Labels:    labels,
+					Merged:    pr.Merged,
+					Url:       pr.Url.String(),
+					CoAuthors: provider.ParseCoAuthors(commit.Message),
+
+					ClosingIssues: pr.ClosingIssuesReferences.issues(),
+				})
 			}
 		}
 
-		if !pullRequestsQuery.Repository.Ref.Target.Commit.History.PageInfo.HasNextPage {
+		if !pullRequestsQuery.Repository.Object.Commit.History.PageInfo.HasNextPage {
+			logger.Debugf("scanned all %d commits of %s for pull requests", scanned, branch)
 			return pullRequests, nil
 		}
 
-		pullRequestsVariables["commitCursor"] = pullRequestsQuery.Repository.Ref.Target.Commit.History.PageInfo.EndCursor
+		pullRequestsVariables["commitCursor"] = pullRequestsQuery.Repository.Object.Commit.History.PageInfo.EndCursor
 	}
 }
 
-func (g GitHub) FetchLabelsForPullRequest(owner, repo string, pullRequestNumber int) ([]string, error) {
-	var PullRequestlabelsQuery struct {
+// FetchCommit returns the commit with the date it was committed.
+func (g GitHub) FetchCommit(owner, repo, commitSHA string) (provider.Commit, error) {
+	var commitQuery struct {
 		Repository struct {
-			PullRequest struct {
-				Labels struct {
-					Nodes []struct {
-						Name string
-					}
-				} `graphql:"labels(first: 10)"`
-			} `graphql:"pullRequest(number: $prNumber)"`
+			Object *struct {
+				Commit struct {
+					Oid           string
+					CommittedDate githubv4.DateTime
+				} `graphql:"... on Commit"`
+			} `graphql:"object(expression: $sha)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
+	}
+
+	err := g.query(&commitQuery, map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"name":  githubv4.String(repo),
+		"sha":   githubv4.String(commitSHA),
+	})
+	if err != nil {
+		return provider.Commit{}, err
+	}
+
+	if commitQuery.Repository.Object == nil {
+		return provider.Commit{}, fmt.Errorf("commit %s does not exist in %s/%s", commitSHA, owner, repo)
+	}
+
+	return provider.Commit{
+		SHA:           commitQuery.Repository.Object.Commit.Oid,
+		CommittedDate: commitQuery.Repository.Object.Commit.CommittedDate.Time,
+	}, nil
+}
+
+// HasMergedPullRequestBefore searches for a pull request by the author that
+// was merged by the time the commit was made.
+func (g GitHub) HasMergedPullRequestBefore(owner, repo, author string, commit provider.Commit) (bool, error) {
+	var searchQuery struct {
+		Search struct {
+			IssueCount int
+		} `graphql:"search(query: $query, type: ISSUE, first: 1)"`
+		RateLimit *rateLimit
+	}
+
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s merged:<=%s", owner, repo, author, commit.CommittedDate.UTC().Format(time.RFC3339))
+	err := g.query(&searchQuery, map[string]interface{}{
+		"query": githubv4.String(query),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return searchQuery.Search.IssueCount > 0, nil
+}
+
+func (g GitHub) FetchPullRequest(owner, repo string, pullRequestNumber int) (provider.PullRequest, error) {
+	var pullRequestQuery struct {
+		Repository struct {
+			PullRequest pullRequest `graphql:"pullRequest(number: $prNumber)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
 	}
 
 	PRVariables := map[string]interface{}{
@@ -275,15 +535,28 @@ func (g GitHub) FetchLabelsForPullRequest(owner, repo string, pullRequestNumber
 		"prNumber": githubv4.Int(pullRequestNumber),
 	}
 
-	err := g.client.Query(context.Background(), &PullRequestlabelsQuery, PRVariables)
+	err := g.query(&pullRequestQuery, PRVariables)
 	if err != nil {
-		return nil, err
+		return provider.PullRequest{}, err
 	}
 
-	var labels []string
-	for _, node := range PullRequestlabelsQuery.Repository.PullRequest.Labels.Nodes {
-		labels = append(labels, node.Name)
+	pr := pullRequestQuery.Repository.PullRequest
+
+	labels, err := g.allLabels(owner, repo, pr)
+	if err != nil {
+		return provider.PullRequest{}, err
 	}
 
-	return labels, nil
+	return provider.PullRequest{
+		ID:     pr.ID,
+		Number: pr.Number,
+		Title:  pr.Title,
+		Body:   pr.Body,
+		Author: pr.Author.Login,
+		Labels: labels,
+		Merged: pr.Merged,
+		Url:    pr.Url.String(),
+
+		ClosingIssues: pr.ClosingIssuesReferences.issues(),
+	}, nil
 }
@@ -0,0 +1,332 @@
+package github
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLURL(t *testing.T) {
+	for _, test := range []struct {
+		apiURL     string
+		graphQLURL string
+	}{
+		{"https://api.github.com", "https://api.github.com/graphql"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/graphql"},
+		{"https://example.com/github/api/v3", "https://example.com/github/api/graphql"},
+		{"http://localhost:8080", "http://localhost:8080/graphql"},
+	} {
+		require.Equal(t, test.graphQLURL, graphQLURL(test.apiURL), test.apiURL)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "releaseme")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	caBundle := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0644))
+
+	notPEM := filepath.Join(dir, "not-pem")
+	require.NoError(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0644))
+
+	t.Run("trusts the system certificates by default", func(t *testing.T) {
+		transport, err := newTransport(Options{})
+		require.NoError(t, err)
+
+		_, err = (&http.Client{Transport: transport}).Get(server.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("trusts the certificates of the ca bundle", func(t *testing.T) {
+		transport, err := newTransport(Options{CABundle: caBundle})
+		require.NoError(t, err)
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/api/graphql")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("fails with an invalid ca bundle", func(t *testing.T) {
+		_, err := newTransport(Options{CABundle: filepath.Join(dir, "missing.pem")})
+		require.Error(t, err)
+
+		_, err = newTransport(Options{CABundle: notPEM})
+		require.EqualError(t, err, "no certificates found in ca bundle "+notPEM)
+	})
+
+	t.Run("sends requests through the proxy", func(t *testing.T) {
+		transport, err := newTransport(Options{Proxy: "http://proxy.example.com:3128"})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodGet, "https://api.github.com/graphql", nil)
+		require.NoError(t, err)
+
+		proxy, err := transport.Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, "http://proxy.example.com:3128", proxy.String())
+	})
+
+	t.Run("fails with an invalid proxy", func(t *testing.T) {
+		_, err := newTransport(Options{Proxy: "http://[::1"})
+		require.Error(t, err)
+	})
+}
+
+func TestEnterpriseServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "releaseme")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var paths []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"data": {"repository": {"labels": {"nodes": []}}}}`))
+	}))
+	defer server.Close()
+
+	caBundle := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0644))
+
+	client, err := New("some-token", Options{APIURL: server.URL + "/api/v3/", CABundle: caBundle})
+	require.NoError(t, err)
+
+	var query struct {
+		Repository struct {
+			Labels struct {
+				Nodes []struct {
+					Name string
+				}
+			} `graphql:"labels(first: 1)"`
+		} `graphql:"repository(owner: \"clarafu\", name: \"release-me\")"`
+	}
+	require.NoError(t, client.query(&query, nil))
+	require.NoError(t, client.CreateCommitStatus("clarafu", "release-me", "abc123", CommitStatus{State: "success"}))
+	require.Equal(t, []string{"/api/graphql", "/api/v3/repos/clarafu/release-me/statuses/abc123"}, paths)
+}
+
+// graphQLServer responds to each GraphQL query with the first response whose
+// key is a substring of the request
+func graphQLServer(t *testing.T, responses [][2]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		for i, response := range responses {
+			if strings.Contains(string(body), response[0]) {
+				responses = append(responses[:i], responses[i+1:]...)
+				w.Write([]byte(response[1]))
+				return
+			}
+		}
+
+		t.Errorf("unexpected query: %s", body)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+}
+
+func TestFetchCommitsFromReleases(t *testing.T) {
+	server := graphQLServer(t, [][2]string{
+		{`"releaseCursor":null`, `{"data": {"repository": {"releases": {
+			"nodes": [
+				{"tag": {"name": "v2.0.0", "target": {"oid": "sha-2"}}},
+				{"tag": {"name": "v1.1.0", "target": {"oid": "tag-object-1", "target": {"oid": "sha-1"}}}}
+			],
+			"pageInfo": {"endCursor": "c1", "hasNextPage": true}
+		}}}}`},
+		{`"releaseCursor":"c1"`, `{"data": {"repository": {"releases": {
+			"nodes": [{"tag": {"name": "v1.0.0", "target": {"oid": "sha-0"}}}],
+			"pageInfo": {"endCursor": "c2", "hasNextPage": false}
+		}}}}`},
+	})
+	defer server.Close()
+
+	client, err := New("some-token", Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	releaseSHAs, err := client.FetchCommitsFromReleases("clarafu", "release-me")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"sha-2": "v2.0.0",
+		"sha-1": "v1.1.0",
+		"sha-0": "v1.0.0",
+	}, releaseSHAs)
+}
+
+func TestFetchCommitsFromTags(t *testing.T) {
+	server := graphQLServer(t, [][2]string{
+		{`"tagCursor":null`, `{"data": {"repository": {"refs": {
+			"nodes": [
+				{"name": "v1.0.0", "target": {"oid": "sha-0"}},
+				{"name": "v1.1.0", "target": {"oid": "tag-object-1", "target": {"oid": "sha-1"}}}
+			],
+			"pageInfo": {"endCursor": "c1", "hasNextPage": true}
+		}}}}`},
+		{`"tagCursor":"c1"`, `{"data": {"repository": {"refs": {
+			"nodes": [{"name": "nightly", "target": {"oid": "tag-object-2", "target": {"oid": "sha-2"}}}],
+			"pageInfo": {"endCursor": "c2", "hasNextPage": false}
+		}}}}`},
+	})
+	defer server.Close()
+
+	client, err := New("some-token", Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	tagSHAs, err := client.FetchCommitsFromTags("clarafu", "release-me")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"sha-0": "v1.0.0",
+		"sha-1": "v1.1.0",
+		"sha-2": "nightly",
+	}, tagSHAs)
+}
+
+func TestHasMergedPullRequestBefore(t *testing.T) {
+	server := graphQLServer(t, [][2]string{
+		{`"sha":"v1.0.0"`, `{"data": {"repository": {"object": {"oid": "sha-1", "committedDate": "2020-06-01T00:00:00Z"}}}}`},
+		{`author:alice merged:\u003c=2020-06-01T00:00:00Z`, `{"data": {"search": {"issueCount": 2}}}`},
+		{`author:bob merged:\u003c=2020-06-01T00:00:00Z`, `{"data": {"search": {"issueCount": 0}}}`},
+	})
+	defer server.Close()
+
+	client, err := New("some-token", Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	commit, err := client.FetchCommit("clarafu", "release-me", "v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, provider.Commit{SHA: "sha-1", CommittedDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)}, commit)
+
+	contributed, err := client.HasMergedPullRequestBefore("clarafu", "release-me", "alice", commit)
+	require.NoError(t, err)
+	require.True(t, contributed)
+
+	contributed, err = client.HasMergedPullRequestBefore("clarafu", "release-me", "bob", commit)
+	require.NoError(t, err)
+	require.False(t, contributed)
+}
+
+func TestFetchLatestReleaseCommitFromBranch(t *testing.T) {
+	responses := func() [][2]string {
+		return [][2]string{
+			{`"commitCursor":null`, `{"data": {"repository": {"object": {"history": {
+				"nodes": [{"oid": "sha-5"}, {"oid": "sha-4"}],
+				"pageInfo": {"endCursor": "c1", "hasNextPage": true}
+			}}}}}`},
+			{`"commitCursor":"c1"`, `{"data": {"repository": {"object": {"history": {
+				"nodes": [{"oid": "sha-3"}, {"oid": "sha-2"}, {"oid": "sha-1"}],
+				"pageInfo": {"endCursor": "c2", "hasNextPage": false}
+			}}}}}`},
+		}
+	}
+
+	t.Run("walks the history of the revision to the previous release", func(t *testing.T) {
+		server := graphQLServer(t, responses())
+		defer server.Close()
+
+		client, err := New("some-token", Options{APIURL: server.URL})
+		require.NoError(t, err)
+
+		commit, err := client.FetchLatestReleaseCommitFromBranch("clarafu", "release-me", "v1.2.0", "v1.2.0", map[string]string{
+			"sha-4": "v1.1.1",
+			"sha-2": "v1.1.0",
+			"sha-1": "v1.0.0",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "sha-2", commit)
+	})
+
+	t.Run("returns the oldest commit without a previous release", func(t *testing.T) {
+		server := graphQLServer(t, responses())
+		defer server.Close()
+
+		client, err := New("some-token", Options{APIURL: server.URL})
+		require.NoError(t, err)
+
+		commit, err := client.FetchLatestReleaseCommitFromBranch("clarafu", "release-me", "master", "v1.0.0", map[string]string{})
+		require.NoError(t, err)
+		require.Equal(t, "sha-1", commit)
+	})
+}
+
+// historyPage is a page of the history of a branch, with the pull requests
+// associated to each commit
+func historyPage(endCursor string, hasNextPage bool, commits ...string) string {
+	return `{"data": {"repository": {"object": {"history": {
+		"nodes": [` + strings.Join(commits, ",") + `],
+		"pageInfo": {"endCursor": "` + endCursor + `", "hasNextPage": ` + strconv.FormatBool(hasNextPage) + `}
+	}}}}}`
+}
+
+func TestFetchPullRequestsAfterCommit(t *testing.T) {
+	server := graphQLServer(t, [][2]string{
+		{`"branch":"master","commitCursor":null`, historyPage("c1", true,
+			`{"oid": "sha-6", "associatedPullRequests": {"nodes": [{"id": "pr-6", "number": 6, "merged": true}]}}`,
+			`{"oid": "sha-5", "associatedPullRequests": {"nodes": [{"id": "pr-5", "number": 5, "merged": true, "author": {"login": "alice"}, "url": "https://github.com/clarafu/release-me/pull/5", "labels": {"nodes": [{"name": "bug"}]}}]}}`,
+		)},
+		{`"branch":"master","commitCursor":"c1"`, historyPage("c2", true,
+			`{"oid": "sha-4", "associatedPullRequests": {"nodes": [{"id": "pr-5", "number": 5, "merged": true, "author": {"login": "alice"}}]}}`,
+			`{"oid": "sha-3", "associatedPullRequests": {"nodes": [
+				{"id": "pr-4", "number": 4, "merged": false, "author": {"login": "bob"}},
+				{"id": "pr-3", "number": 3, "merged": true, "author": {"login": "dependabot"}},
+				{"id": "pr-2", "number": 2, "merged": true, "author": {"login": "bob"}, "url": "https://github.com/clarafu/release-me/pull/2", "labels": {"nodes": [{"name": "enhancement"}]}}
+			]}}`,
+			`{"oid": "sha-2", "associatedPullRequests": {"nodes": [{"id": "pr-1", "number": 1, "merged": true}]}}`,
+		)},
+	})
+	defer server.Close()
+
+	client, err := New("some-token", Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	pullRequests, err := client.FetchPullRequestsAfterCommit("clarafu", "release-me", "master", "sha-2", "sha-5", []string{"dependabot"})
+	require.NoError(t, err)
+	require.Equal(t, []provider.PullRequest{
+		{ID: "pr-5", Number: 5, Author: "alice", Labels: []string{"bug"}, Merged: true, Url: "https://github.com/clarafu/release-me/pull/5"},
+		{ID: "pr-2", Number: 2, Author: "bob", Labels: []string{"enhancement"}, Merged: true, Url: "https://github.com/clarafu/release-me/pull/2"},
+	}, pullRequests)
+}
+
+func TestCollectsCoAuthorsFromLaterCommitsOfPullRequest(t *testing.T) {
+	server := graphQLServer(t, [][2]string{
+		{`"commitCursor":null`, historyPage("c1", false,
+			`{"oid": "sha-4", "message": "Merge pull request #2 from bob/feature", "associatedPullRequests": {"nodes": [{"id": "pr-2", "number": 2, "merged": true, "author": {"login": "bob"}, "url": "https://github.com/clarafu/release-me/pull/2"}]}}`,
+			`{"oid": "sha-3", "message": "Add a feature\n\nCo-authored-by: Carol <2+carol@users.noreply.github.com>", "associatedPullRequests": {"nodes": [{"id": "pr-2", "number": 2, "merged": true, "author": {"login": "bob"}}]}}`,
+			`{"oid": "sha-2", "message": "Fix a typo\n\nCo-authored-by: Carol <2+carol@users.noreply.github.com>\nCo-authored-by: Dan <dan@example.com>", "associatedPullRequests": {"nodes": [{"id": "pr-2", "number": 2, "merged": true, "author": {"login": "bob"}}]}}`,
+			`{"oid": "sha-1"}`,
+		)},
+	})
+	defer server.Close()
+
+	client, err := New("some-token", Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	pullRequests, err := client.FetchPullRequestsAfterCommit("clarafu", "release-me", "master", "sha-1", "", nil)
+	require.NoError(t, err)
+	require.Len(t, pullRequests, 1)
+	require.Equal(t, []provider.CoAuthor{
+		{Name: "Carol", Email: "2+carol@users.noreply.github.com"},
+		{Name: "Dan", Email: "dan@example.com"},
+	}, pullRequests[0].CoAuthors)
+}
@@ -0,0 +1,172 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+	"github.com/shurcooL/githubv4"
+)
+
+type pageInfo struct {
+	EndCursor   githubv4.String
+	HasNextPage bool
+}
+
+// pullRequest is queried wherever pull requests are read. Only the first
+// page of labels is queried alongside the pull request; the rest are fetched
+// by allLabels.
+type pullRequest struct {
+	ID     string
+	Title  string
+	Body   string
+	Author struct {
+		Login string
+	}
+	Labels                  labels        `graphql:"labels(first: 10)"`
+	ClosingIssuesReferences closingIssues `graphql:"closingIssuesReferences(first: 10)"`
+	Number                  int
+	Merged                  bool
+	Url                     githubv4.URI
+}
+
+type labels struct {
+	Nodes []struct {
+		Name string
+	}
+	PageInfo pageInfo
+}
+
+// associatedPullRequests are the pull requests that a commit belongs to. Only
+// the first page is queried alongside the commit; the rest are fetched by
+// allAssociatedPullRequests.
+type associatedPullRequests struct {
+	Nodes    []pullRequest
+	PageInfo pageInfo
+}
+
+// closingIssues are the issues that a pull request closes when it is merged
+type closingIssues struct {
+	Nodes []struct {
+		Number int
+		Url    githubv4.URI
+	}
+}
+
+func (c closingIssues) issues() []provider.Issue {
+	var issues []provider.Issue
+	for _, node := range c.Nodes {
+		issues = append(issues, provider.Issue{
+			Number: node.Number,
+			Url:    node.Url.String(),
+		})
+	}
+	return issues
+}
+
+// allLabels returns every label of the pull request, fetching the labels that
+// did not fit in the first page. A pull request losing labels would otherwise
+// be placed into the wrong section, or fail to be placed at all.
+func (g GitHub) allLabels(owner, repo string, pr pullRequest) ([]string, error) {
+	var names []string
+	for _, node := range pr.Labels.Nodes {
+		names = append(names, node.Name)
+	}
+
+	if !pr.Labels.PageInfo.HasNextPage {
+		return names, nil
+	}
+
+	logger.Warnf("pull request #%d has more than %d labels, fetching the rest", pr.Number, len(pr.Labels.Nodes))
+
+	rest, err := g.fetchLabels(owner, repo, pr.Number, &pr.Labels.PageInfo.EndCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(names, rest...), nil
+}
+
+// fetchLabels returns the labels of the pull request after the cursor, or
+// every label if the cursor is nil.
+func (g GitHub) fetchLabels(owner, repo string, pullRequestNumber int, cursor *githubv4.String) ([]string, error) {
+	var labelsQuery struct {
+		Repository struct {
+			PullRequest struct {
+				Labels labels `graphql:"labels(first: 100, after: $labelCursor)"`
+			} `graphql:"pullRequest(number: $prNumber)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
+	}
+
+	labelsVariables := map[string]interface{}{
+		"owner":       githubv4.String(owner),
+		"name":        githubv4.String(repo),
+		"prNumber":    githubv4.Int(pullRequestNumber),
+		"labelCursor": cursor,
+	}
+
+	var names []string
+	for {
+		err := g.query(&labelsQuery, labelsVariables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch labels of pull request #%d: %w", pullRequestNumber, err)
+		}
+
+		page := labelsQuery.Repository.PullRequest.Labels
+		for _, node := range page.Nodes {
+			names = append(names, node.Name)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			return names, nil
+		}
+
+		labelsVariables["labelCursor"] = &page.PageInfo.EndCursor
+	}
+}
+
+// allAssociatedPullRequests returns every pull request that the commit
+// belongs to, fetching the pull requests that did not fit in the first page.
+func (g GitHub) allAssociatedPullRequests(owner, repo, commitSHA string, first associatedPullRequests) ([]pullRequest, error) {
+	if !first.PageInfo.HasNextPage {
+		return first.Nodes, nil
+	}
+
+	logger.Warnf("commit %s belongs to more than %d pull requests, fetching the rest", commitSHA, len(first.Nodes))
+
+	var associatedQuery struct {
+		Repository struct {
+			Object struct {
+				Commit struct {
+					AssociatedPullRequests associatedPullRequests `graphql:"associatedPullRequests(first: 100, after: $prCursor)"`
+				} `graphql:"... on Commit"`
+			} `graphql:"object(expression: $sha)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit *rateLimit
+	}
+
+	associatedVariables := map[string]interface{}{
+		"owner":    githubv4.String(owner),
+		"name":     githubv4.String(repo),
+		"sha":      githubv4.String(commitSHA),
+		"prCursor": &first.PageInfo.EndCursor,
+	}
+
+	pullRequests := append([]pullRequest{}, first.Nodes...)
+	for {
+		err := g.query(&associatedQuery, associatedVariables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull requests of commit %s: %w", commitSHA, err)
+		}
+
+		page := associatedQuery.Repository.Object.Commit.AssociatedPullRequests
+		pullRequests = append(pullRequests, page.Nodes...)
+
+		if !page.PageInfo.HasNextPage {
+			return pullRequests, nil
+		}
+
+		associatedVariables["prCursor"] = &page.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPullRequest(t *testing.T) {
+	suite.Run(t, &PullRequestSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type PullRequestSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	// responses are matched to the GraphQL query by a substring of the
+	// request, in order
+	responses [][2]string
+	requests  []string
+
+	server *httptest.Server
+	client GitHub
+}
+
+func (s *PullRequestSuite) SetupTest() {
+	s.responses = nil
+	s.requests = nil
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.requests = append(s.requests, string(body))
+
+		for i, response := range s.responses {
+			if strings.Contains(string(body), response[0]) {
+				s.responses = append(s.responses[:i], s.responses[i+1:]...)
+				w.Write([]byte(response[1]))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	var err error
+	s.client, err = New("some-token", Options{APIURL: s.server.URL})
+	s.NoError(err)
+}
+
+func (s *PullRequestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *PullRequestSuite) TestFetchesLabelsBeyondTheFirstPage() {
+	s.responses = [][2]string{
+		{`labels(first: 10)`, `{"data": {"repository": {"pullRequest": {
+			"number": 7,
+			"url": "https://github.com/clarafu/release-me/pull/7",
+			"labels": {"nodes": [{"name": "area/web"}], "pageInfo": {"endCursor": "c1", "hasNextPage": true}}
+		}}}}`},
+		{`"labelCursor":"c1"`, `{"data": {"repository": {"pullRequest": {
+			"labels": {"nodes": [{"name": "area/api"}], "pageInfo": {"endCursor": "c2", "hasNextPage": true}}
+		}}}}`},
+		{`"labelCursor":"c2"`, `{"data": {"repository": {"pullRequest": {
+			"labels": {"nodes": [{"name": "bug"}], "pageInfo": {"endCursor": "c3", "hasNextPage": false}}
+		}}}}`},
+	}
+
+	pr, err := s.client.FetchPullRequest("clarafu", "release-me", 7)
+	s.NoError(err)
+	s.Equal([]string{"area/web", "area/api", "bug"}, pr.Labels)
+	s.Empty(s.responses)
+}
+
+func (s *PullRequestSuite) TestFetchLabels() {
+	s.responses = [][2]string{
+		{`"labelCursor":null`, `{"data": {"repository": {"pullRequest": {
+			"labels": {"nodes": [{"name": "area/web"}], "pageInfo": {"endCursor": "c1", "hasNextPage": true}}
+		}}}}`},
+		{`"labelCursor":"c1"`, `{"data": {"repository": {"pullRequest": {
+			"labels": {"nodes": [{"name": "bug"}], "pageInfo": {"hasNextPage": false}}
+		}}}}`},
+	}
+
+	labels, err := s.client.fetchLabels("clarafu", "release-me", 7, nil)
+	s.NoError(err)
+	s.Equal([]string{"area/web", "bug"}, labels)
+}
+
+func (s *PullRequestSuite) TestFetchesAssociatedPullRequestsBeyondTheFirstPage() {
+	s.responses = [][2]string{
+		{`"prCursor":"c1"`, `{"data": {"repository": {"object": {
+			"associatedPullRequests": {
+				"nodes": [{"id": "pr-3", "number": 3, "merged": true, "labels": {"nodes": [{"name": "bug"}]}}],
+				"pageInfo": {"hasNextPage": false}
+			}
+		}}}}`},
+	}
+
+	first := associatedPullRequests{
+		Nodes:    []pullRequest{{ID: "pr-1", Number: 1}, {ID: "pr-2", Number: 2}},
+		PageInfo: pageInfo{EndCursor: "c1", HasNextPage: true},
+	}
+
+	pullRequests, err := s.client.allAssociatedPullRequests("clarafu", "release-me", "abc123", first)
+	s.NoError(err)
+	s.Len(pullRequests, 3)
+	s.Equal(3, pullRequests[2].Number)
+	s.Contains(s.requests[0], `"sha":"abc123"`)
+}
+
+func (s *PullRequestSuite) TestDoesNotFetchCompletePages() {
+	first := associatedPullRequests{
+		Nodes: []pullRequest{{ID: "pr-1", Number: 1}},
+	}
+
+	pullRequests, err := s.client.allAssociatedPullRequests("clarafu", "release-me", "abc123", first)
+	s.NoError(err)
+	s.Equal(first.Nodes, pullRequests)
+
+	labels, err := s.client.allLabels("clarafu", "release-me", pullRequest{})
+	s.NoError(err)
+	s.Empty(labels)
+	s.Empty(s.requests)
+}
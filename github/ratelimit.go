@@ -0,0 +1,254 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+)
+
+// RateLimitError is returned when the rate limit of the GitHub API is
+// exhausted and requests will fail until it resets.
+type RateLimitError struct {
+	ResetAt time.Time
+}
+
+func (e RateLimitError) Error() string {
+	if e.ResetAt.IsZero() {
+		return "github api rate limit exhausted"
+	}
+	return fmt.Sprintf("github api rate limit exhausted, it resets at %s (in %s)", e.ResetAt.Local().Format(time.RFC1123), time.Until(e.ResetAt).Round(time.Second))
+}
+
+// rateLimit is requested alongside every GraphQL query to keep track of the
+// remaining budget. It is nil if rate limiting is disabled, which is possible
+// on GitHub Enterprise Server.
+type rateLimit struct {
+	Cost      int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// rateLimiter remembers the budget reported by the latest query, so that a
+// query that would exceed the budget fails without being sent.
+type rateLimiter struct {
+	mu   sync.Mutex
+	last *rateLimit
+}
+
+func (l *rateLimiter) check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.last != nil && l.last.Remaining < l.last.Cost && time.Now().Before(l.last.ResetAt) {
+		return RateLimitError{ResetAt: l.last.ResetAt}
+	}
+
+	return nil
+}
+
+func (l *rateLimiter) record(limit *rateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.last = limit
+}
+
+func (l *rateLimiter) resetAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.last == nil {
+		return time.Time{}
+	}
+	return l.last.ResetAt
+}
+
+// query runs the GraphQL query. If the query struct has a RateLimit field of
+// type *rateLimit, the budget it reports is used to fail before sending a
+// query once the budget is exhausted.
+func (g GitHub) query(q interface{}, variables map[string]interface{}) error {
+	err := g.limiter.check()
+	if err != nil {
+		return err
+	}
+
+	err = g.client.Query(context.Background(), q, variables)
+	if err != nil {
+		var rateLimitErr RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			return rateLimitErr
+		}
+
+		// Running out of budget in the middle of a query is reported as a
+		// GraphQL error rather than through the status code
+		if strings.Contains(err.Error(), "API rate limit exceeded") {
+			return RateLimitError{ResetAt: g.limiter.resetAt()}
+		}
+
+		return err
+	}
+
+	field := reflect.ValueOf(q).Elem().FieldByName("RateLimit")
+	if field.IsValid() {
+		if limit, ok := field.Interface().(*rateLimit); ok {
+			g.limiter.record(limit)
+		}
+	}
+
+	return nil
+}
+
+// retryTransport retries requests that fail because of server errors or rate
+// limits, using exponential backoff with jitter. When GitHub says how long to
+// wait, through the Retry-After header or the reset of an exhausted primary
+// rate limit, the request is retried after exactly that long. If that is
+// longer than maxDelay, which it can be by up to an hour for the primary rate
+// limit, a RateLimitError is returned straight away instead.
+type retryTransport struct {
+	base http.RoundTripper
+
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	sleep       func(time.Duration)
+	now         func() time.Time
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		base:        base,
+		maxAttempts: 5,
+		baseDelay:   time.Second,
+		maxDelay:    time.Minute,
+		sleep:       time.Sleep,
+		now:         time.Now,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		// A round tripper must not modify the request, so retries are sent
+		// as a clone with the body rewound
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(req.Context())
+
+			if req.Body != nil {
+				if req.GetBody == nil {
+					return nil, fmt.Errorf("failed to retry request: body can not be rewound")
+				}
+
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, fmt.Errorf("failed to retry request: %w", err)
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		delay, retry, err := t.shouldRetry(resp, err)
+		if retry && delay > t.maxDelay {
+			resp.Body.Close()
+			return nil, RateLimitError{ResetAt: t.now().Add(delay)}
+		}
+
+		if !retry || attempt >= t.maxAttempts || req.Context().Err() != nil {
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if delay == 0 {
+			delay = t.backoff(attempt)
+		}
+
+		logger.Debugf("retrying %s %s in %s, attempt %d of %d", req.Method, req.URL.Path, delay, attempt+1, t.maxAttempts)
+		t.sleep(delay)
+	}
+}
+
+// shouldRetry decides whether the response is worth retrying, and how long
+// GitHub asked to wait before retrying. A zero delay uses the backoff. A
+// delay is only returned along with a response.
+func (t *retryTransport) shouldRetry(resp *http.Response, err error) (time.Duration, bool, error) {
+	if err != nil {
+		return 0, true, err
+	}
+
+	if resp.StatusCode >= 500 {
+		return 0, true, nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return 0, false, provider.AuthenticationFailed{Provider: "github", Status: resp.Status}
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false, nil
+	}
+
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(retryAfter) * time.Second, true, nil
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			resp.Body.Close()
+			return 0, false, RateLimitError{}
+		}
+
+		// A reset in the past uses the backoff
+		delay := time.Unix(reset, 0).Sub(t.now())
+		if delay <= 0 {
+			return 0, true, nil
+		}
+		return delay, true, nil
+	}
+
+	// Secondary rate limits are only recognisable through the message in the
+	// body, which has to be put back for the caller to read
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 0, false, nil
+	}
+
+	message := strings.ToLower(string(body))
+	if strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse") {
+		return 0, true, nil
+	}
+
+	return 0, false, nil
+}
+
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << uint(attempt-1)
+	if delay > t.maxDelay || delay <= 0 {
+		delay = t.maxDelay
+	}
+
+	// Full jitter spreads out retries from concurrent runs
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRateLimit(t *testing.T) {
+	suite.Run(t, &RateLimitSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type RateLimitSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	responses []func(w http.ResponseWriter)
+	requests  []string
+	slept     []time.Duration
+
+	now time.Time
+
+	server *httptest.Server
+	client *http.Client
+}
+
+func (s *RateLimitSuite) SetupTest() {
+	s.responses = nil
+	s.requests = nil
+	s.slept = nil
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.requests = append(s.requests, string(body))
+
+		respond := s.responses[0]
+		s.responses = s.responses[1:]
+		respond(w)
+	}))
+
+	s.now = time.Unix(1600000000, 0)
+
+	transport := newRetryTransport(http.DefaultTransport)
+	transport.sleep = func(d time.Duration) {
+		s.slept = append(s.slept, d)
+	}
+	transport.now = func() time.Time {
+		return s.now
+	}
+	s.client = &http.Client{Transport: transport}
+}
+
+func (s *RateLimitSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func status(code int, header map[string]string, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}
+}
+
+func (s *RateLimitSuite) post() (*http.Response, error) {
+	return s.client.Post(s.server.URL, "application/json", strings.NewReader(`{"query":"{}"}`))
+}
+
+func (s *RateLimitSuite) TestRetriesServerErrors() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusBadGateway, nil, "bad gateway"),
+		status(http.StatusServiceUnavailable, nil, "unavailable"),
+		status(http.StatusOK, nil, "ok"),
+	}
+
+	resp, err := s.post()
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	s.Equal([]string{`{"query":"{}"}`, `{"query":"{}"}`, `{"query":"{}"}`}, s.requests)
+	s.Len(s.slept, 2)
+	s.True(s.slept[0] <= time.Second)
+	s.True(s.slept[1] <= 2*time.Second)
+}
+
+func (s *RateLimitSuite) TestDoesNotModifyRetriedRequest() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusBadGateway, nil, "bad gateway"),
+		status(http.StatusOK, nil, "ok"),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.server.URL, strings.NewReader(`{"query":"{}"}`))
+	s.NoError(err)
+	body := req.Body
+
+	resp, err := s.client.Transport.RoundTrip(req)
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	s.True(body == req.Body, "the body of the request was replaced")
+	s.Equal([]string{`{"query":"{}"}`, `{"query":"{}"}`}, s.requests)
+}
+
+func (s *RateLimitSuite) TestGivesUpAfterMaxAttempts() {
+	for i := 0; i < 5; i++ {
+		s.responses = append(s.responses, status(http.StatusBadGateway, nil, "bad gateway"))
+	}
+
+	resp, err := s.post()
+	s.NoError(err)
+	s.Equal(http.StatusBadGateway, resp.StatusCode)
+	s.Len(s.requests, 5)
+}
+
+func (s *RateLimitSuite) TestRetriesSecondaryRateLimits() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusForbidden, map[string]string{"Retry-After": "3"}, "slow down"),
+		status(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+		status(http.StatusOK, nil, "ok"),
+	}
+
+	resp, err := s.post()
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Len(s.requests, 3)
+	s.Equal(3*time.Second, s.slept[0])
+}
+
+func (s *RateLimitSuite) TestDoesNotRetryForbidden() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`),
+	}
+
+	resp, err := s.post()
+	s.NoError(err)
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	s.NoError(err)
+	s.Equal(`{"message":"Resource not accessible by integration"}`, string(body))
+}
+
+func (s *RateLimitSuite) TestFailsWhenUnauthorized() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusUnauthorized, nil, `{"message":"Bad credentials"}`),
+	}
+
+	_, err := s.post()
+
+	var authErr provider.AuthenticationFailed
+	s.True(errors.As(err, &authErr))
+	s.Equal("401 Unauthorized", authErr.Status)
+	s.Len(s.requests, 1)
+}
+
+func (s *RateLimitSuite) TestFailsWhenRateLimitIsExhausted() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusForbidden, map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     "1600003600",
+		}, "API rate limit exceeded"),
+	}
+
+	_, err := s.post()
+
+	var rateLimitErr RateLimitError
+	s.True(errors.As(err, &rateLimitErr))
+	s.Equal(time.Unix(1600003600, 0), rateLimitErr.ResetAt)
+	s.Len(s.requests, 1)
+	s.Empty(s.slept)
+}
+
+func (s *RateLimitSuite) TestWaitsForRateLimitToReset() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusForbidden, map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     "1600000030",
+		}, "API rate limit exceeded"),
+		status(http.StatusOK, nil, "ok"),
+	}
+
+	resp, err := s.post()
+	s.NoError(err)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal([]time.Duration{30 * time.Second}, s.slept)
+}
+
+func (s *RateLimitSuite) TestFailsWhenRetryAfterIsTooLong() {
+	s.responses = []func(http.ResponseWriter){
+		status(http.StatusTooManyRequests, map[string]string{"Retry-After": "600"}, "slow down"),
+	}
+
+	_, err := s.post()
+
+	var rateLimitErr RateLimitError
+	s.True(errors.As(err, &rateLimitErr))
+	s.Equal(s.now.Add(10*time.Minute), rateLimitErr.ResetAt)
+	s.Len(s.requests, 1)
+	s.Empty(s.slept)
+}
+
+func (s *RateLimitSuite) TestLimiterFailsOnceBudgetIsExhausted() {
+	limiter := &rateLimiter{}
+	s.NoError(limiter.check())
+
+	resetAt := time.Now().Add(time.Hour)
+	limiter.record(&rateLimit{Cost: 1, Remaining: 100, ResetAt: resetAt})
+	s.NoError(limiter.check())
+
+	limiter.record(&rateLimit{Cost: 5, Remaining: 4, ResetAt: resetAt})
+	s.Equal(RateLimitError{ResetAt: resetAt}, limiter.check())
+
+	limiter.record(nil)
+	s.NoError(limiter.check())
+}
@@ -0,0 +1,127 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Release is a GitHub release, as represented by the REST API. Releases can
+// not be created through the GraphQL API.
+type Release struct {
+	ID              int64  `json:"id,omitempty"`
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+	HTMLURL         string `json:"html_url,omitempty"`
+}
+
+type ReleaseAlreadyPublished struct {
+	TagName string
+	HTMLURL string
+}
+
+func (e ReleaseAlreadyPublished) Error() string {
+	return fmt.Sprintf("release %s has already been published at %s", e.TagName, e.HTMLURL)
+}
+
+// FetchReleaseByTag returns the release, including drafts, that is associated
+// to the tag. If there is no release for the tag, nil is returned.
+func (g GitHub) FetchReleaseByTag(owner, repo, tag string) (*Release, error) {
+	// The releases/tags/{tag} endpoint does not return draft releases, so
+	// every release needs to be listed to find a draft for the tag.
+	for page := 1; ; page++ {
+		var releases []Release
+		err := g.rest(http.MethodGet, fmt.Sprintf("/repos/%s/%s/releases?per_page=100&page=%d", owner, repo, page), nil, &releases)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		for _, release := range releases {
+			if release.TagName == tag {
+				return &release, nil
+			}
+		}
+
+		if len(releases) < 100 {
+			return nil, nil
+		}
+	}
+}
+
+// PublishRelease creates the release. If a draft release already exists for
+// the same tag, the draft is updated instead of creating another release. A
+// release that is already published is never modified.
+func (g GitHub) PublishRelease(owner, repo string, release Release) (Release, error) {
+	existing, err := g.FetchReleaseByTag(owner, repo, release.TagName)
+	if err != nil {
+		return Release{}, err
+	}
+
+	var published Release
+	if existing == nil {
+		err = g.rest(http.MethodPost, fmt.Sprintf("/repos/%s/%s/releases", owner, repo), release, &published)
+		if err != nil {
+			return Release{}, fmt.Errorf("failed to create release: %w", err)
+		}
+
+		return published, nil
+	}
+
+	if !existing.Draft {
+		return Release{}, ReleaseAlreadyPublished{TagName: existing.TagName, HTMLURL: existing.HTMLURL}
+	}
+
+	err = g.rest(http.MethodPatch, fmt.Sprintf("/repos/%s/%s/releases/%d", owner, repo, existing.ID), release, &published)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to update draft release: %w", err)
+	}
+
+	return published, nil
+}
+
+// rest sends a request to the GitHub REST API, encoding the body and
+// decoding the response as json.
+func (g GitHub) rest(method, path string, body, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, g.apiURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, respBody)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,136 @@
+package github_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clarafu/release-me/github"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRelease(t *testing.T) {
+	suite.Run(t, &ReleaseSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type ReleaseSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	// releases are listed by the releases endpoint
+	releases []github.Release
+
+	// writeStatus is the status returned when creating or updating a release
+	writeStatus int
+
+	requests []string
+	bodies   []string
+
+	server *httptest.Server
+	client github.GitHub
+}
+
+func (s *ReleaseSuite) SetupTest() {
+	s.releases = []github.Release{}
+	s.writeStatus = http.StatusOK
+	s.requests = nil
+	s.bodies = nil
+
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(s.releases)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		s.bodies = append(s.bodies, string(body))
+
+		if s.writeStatus != http.StatusOK {
+			w.WriteHeader(s.writeStatus)
+			w.Write([]byte(`{"message": "Validation Failed"}`))
+			return
+		}
+
+		var release github.Release
+		json.Unmarshal(body, &release)
+		release.ID = 42
+		release.HTMLURL = "https://github.com/clarafu/release-me/releases/tag/" + release.TagName
+		json.NewEncoder(w).Encode(release)
+	}))
+
+	var err error
+	s.client, err = github.New("some-token", github.Options{APIURL: s.server.URL})
+	s.NoError(err)
+}
+
+func (s *ReleaseSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *ReleaseSuite) TestCreatesRelease() {
+	s.releases = []github.Release{{ID: 7, TagName: "v1.0.0", HTMLURL: "https://github.com/clarafu/release-me/releases/tag/v1.0.0"}}
+
+	published, err := s.client.PublishRelease("clarafu", "release-me", github.Release{
+		TagName: "v1.1.0",
+		Name:    "v1.1.0",
+		Body:    "## Features",
+	})
+	s.NoError(err)
+	s.Equal(github.Release{
+		ID:      42,
+		TagName: "v1.1.0",
+		Name:    "v1.1.0",
+		Body:    "## Features",
+		HTMLURL: "https://github.com/clarafu/release-me/releases/tag/v1.1.0",
+	}, published)
+
+	s.Equal([]string{
+		"GET /repos/clarafu/release-me/releases?per_page=100&page=1",
+		"POST /repos/clarafu/release-me/releases",
+	}, s.requests)
+	s.JSONEq(`{"tag_name": "v1.1.0", "name": "v1.1.0", "body": "## Features", "draft": false, "prerelease": false}`, s.bodies[0])
+}
+
+func (s *ReleaseSuite) TestUpdatesDraftRelease() {
+	s.releases = []github.Release{{ID: 9, TagName: "v1.1.0", Name: "v1.1.0", Body: "old", Draft: true}}
+
+	published, err := s.client.PublishRelease("clarafu", "release-me", github.Release{
+		TagName: "v1.1.0",
+		Name:    "v1.1.0",
+		Body:    "## Features",
+		Draft:   true,
+	})
+	s.NoError(err)
+	s.Equal("## Features", published.Body)
+
+	s.Equal([]string{
+		"GET /repos/clarafu/release-me/releases?per_page=100&page=1",
+		"PATCH /repos/clarafu/release-me/releases/9",
+	}, s.requests)
+}
+
+func (s *ReleaseSuite) TestRefusesToModifyPublishedRelease() {
+	s.releases = []github.Release{{ID: 9, TagName: "v1.1.0", HTMLURL: "https://github.com/clarafu/release-me/releases/tag/v1.1.0"}}
+
+	_, err := s.client.PublishRelease("clarafu", "release-me", github.Release{TagName: "v1.1.0"})
+	s.Equal(github.ReleaseAlreadyPublished{
+		TagName: "v1.1.0",
+		HTMLURL: "https://github.com/clarafu/release-me/releases/tag/v1.1.0",
+	}, err)
+
+	s.Equal([]string{"GET /repos/clarafu/release-me/releases?per_page=100&page=1"}, s.requests)
+}
+
+func (s *ReleaseSuite) TestFailsOnErrorResponse() {
+	s.writeStatus = http.StatusUnprocessableEntity
+
+	_, err := s.client.PublishRelease("clarafu", "release-me", github.Release{TagName: "v1.1.0"})
+	s.EqualError(err, `failed to create release: unexpected status 422 Unprocessable Entity: {"message": "Validation Failed"}`)
+}
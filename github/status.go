@@ -0,0 +1,29 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// CommitStatus is the status of a commit, which is shown on every pull
+// request whose head is the commit.
+type CommitStatus struct {
+	// State is one of error, failure, pending or success
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Context tells the status apart from the statuses reported by others
+	Context string `json:"context"`
+}
+
+// CreateCommitStatus reports the status of the commit. A later status with
+// the same context replaces the earlier one.
+func (g GitHub) CreateCommitStatus(owner, repo, sha string, status CommitStatus) error {
+	err := g.rest(http.MethodPost, fmt.Sprintf("/repos/%s/%s/statuses/%s", owner, repo, sha), status, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create commit status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package github_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clarafu/release-me/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCommitStatus(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contents, _ := ioutil.ReadAll(r.Body)
+		body = string(contents)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client, err := github.New("some-token", github.Options{APIURL: server.URL})
+	require.NoError(t, err)
+
+	err = client.CreateCommitStatus("clarafu", "release-me", "abc123", github.CommitStatus{
+		State:       "failure",
+		Description: "must be labelled",
+		Context:     "releaseme/labels",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/repos/clarafu/release-me/statuses/abc123", path)
+	require.JSONEq(t, `{"state": "failure", "description": "must be labelled", "context": "releaseme/labels"}`, body)
+}
@@ -0,0 +1,409 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+)
+
+const perPage = 100
+
+// GitLab reads releases and merge requests through the GitLab REST API. The
+// owner of a repository is the namespace of the project, which can include
+// subgroups, e.g. "group/subgroup".
+type GitLab struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+func New(baseURL, token string) GitLab {
+	return GitLab{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+	}
+}
+
+type mergeRequest struct {
+	ID          int      `json:"id"`
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	State       string   `json:"state"`
+	Labels      []string `json:"labels"`
+	WebURL      string   `json:"web_url"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+func (g GitLab) FetchCommitsFromReleases(owner, repo string) (map[string]string, error) {
+	releaseSHAs := map[string]string{}
+	for page := 1; ; page++ {
+		var releases []struct {
+			TagName string `json:"tag_name"`
+			Commit  struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+
+		err := g.get(projectPath(owner, repo, "/releases"), url.Values{"page": {strconv.Itoa(page)}}, &releases)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch releases from gitlab: %w", err)
+		}
+
+		for _, release := range releases {
+			releaseSHAs[release.Commit.ID] = release.TagName
+		}
+
+		if len(releases) < perPage {
+			return releaseSHAs, nil
+		}
+	}
+}
+
+// FetchCommitsFromTags returns the commit of every tag in the project. GitLab
+// dereferences annotated tags to their commit.
+func (g GitLab) FetchCommitsFromTags(owner, repo string) (map[string]string, error) {
+	tagSHAs := map[string]string{}
+	for page := 1; ; page++ {
+		var tags []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+
+		err := g.get(projectPath(owner, repo, "/repository/tags"), url.Values{"page": {strconv.Itoa(page)}}, &tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tags from gitlab: %w", err)
+		}
+
+		for _, tag := range tags {
+			tagSHAs[tag.Commit.ID] = tag.Name
+		}
+
+		if len(tags) < perPage {
+			return tagSHAs, nil
+		}
+	}
+}
+
+func (g GitLab) FetchLatestReleaseCommitFromBranch(owner, repo, branch, versionToRelease string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, lastCommit, err := g.findReleaseCommit(owner, repo, branch, releaseSHAs, provider.PreviousReleaseFilter(versionToRelease))
+	if err != nil {
+		return "", err
+	}
+
+	if releaseCommit == "" {
+		return lastCommit, nil
+	}
+
+	return releaseCommit, nil
+}
+
+func (g GitLab) FetchMostRecentReleaseCommitFromBranch(owner, repo, branch string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, _, err := g.findReleaseCommit(owner, repo, branch, releaseSHAs, func(string) bool {
+		return true
+	})
+	return releaseCommit, err
+}
+
+func (g GitLab) findReleaseCommit(owner, repo, branch string, releaseSHAs map[string]string, isPrevious func(release string) bool) (string, string, error) {
+	var releaseCommit, lastCommit string
+	var scanned int
+	err := g.walkCommits(owner, repo, branch, func(c commit) (bool, error) {
+		sha := c.ID
+		lastCommit = sha
+		scanned++
+
+		if previousRelease, found := releaseSHAs[sha]; found && isPrevious(previousRelease) {
+			logger.Debugf("scanned %d commits of %s to find release %s", scanned, branch, previousRelease)
+			releaseCommit = sha
+			return false, nil
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if releaseCommit == "" {
+		logger.Debugf("scanned all %d commits of %s without finding a release", scanned, branch)
+	}
+
+	return releaseCommit, lastCommit, nil
+}
+
+func (g GitLab) FetchPullRequestsAfterCommit(owner, repo, branch, startingCommitSHA, lastCommitSHA string, ignoreAuthors []string) ([]provider.PullRequest, error) {
+	var appendCommits bool
+	pullRequests := []provider.PullRequest{}
+	seen := make(map[int]bool)
+	indexes := make(map[int]int)
+
+	filteredAuthors := make(map[string]struct{})
+	for _, username := range ignoreAuthors {
+		filteredAuthors[username] = struct{}{}
+	}
+
+	var scanned int
+	err := g.walkCommits(owner, repo, branch, func(c commit) (bool, error) {
+		sha := c.ID
+		if sha == startingCommitSHA {
+			return false, nil
+		}
+
+		scanned++
+
+		if lastCommitSHA == "" || sha == lastCommitSHA {
+			appendCommits = true
+		}
+
+		var mergeRequests []mergeRequest
+		err := g.get(projectPath(owner, repo, "/repository/commits/"+sha+"/merge_requests"), nil, &mergeRequests)
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch merge requests of commit %s: %w", sha, err)
+		}
+
+		for _, mr := range mergeRequests {
+			if mr.State != "merged" {
+				logger.Debugf("excluding merge request !%d of commit %s: not merged", mr.IID, sha)
+				continue
+			}
+
+			if seen[mr.ID] {
+				logger.Debugf("excluding merge request !%d of commit %s: duplicate of an earlier commit", mr.IID, sha)
+				if i, appended := indexes[mr.ID]; appended {
+					pullRequests[i].AddCoAuthors(provider.ParseCoAuthors(c.Message)...)
+				}
+				continue
+			}
+
+			if _, found := filteredAuthors[mr.Author.Username]; found {
+				logger.Debugf("excluding merge request !%d: author %s is ignored", mr.IID, mr.Author.Username)
+				continue
+			}
+
+			seen[mr.ID] = true
+
+			if !appendCommits {
+				logger.Debugf("excluding merge request !%d: merged after the last commit %s", mr.IID, lastCommitSHA)
+				continue
+			}
+
+			closingIssues, err := g.fetchClosingIssues(owner, repo, mr.IID)
+			if err != nil {
+				return false, err
+			}
+
+			indexes[mr.ID] = len(pullRequests)
+			pullRequests = append(pullRequests, provider.PullRequest{
+				ID:        strconv.Itoa(mr.ID),
+				Number:    mr.IID,
+				Title:     mr.Title,
+				Body:      mr.Description,
+				Author:    mr.Author.Username,
+				Labels:    mr.Labels,
+				Merged:    true,
+				Url:       mr.WebURL,
+				CoAuthors: provider.ParseCoAuthors(c.Message),
+
+				ClosingIssues: closingIssues,
+			})
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("scanned %d commits of %s for merge requests", scanned, branch)
+
+	return pullRequests, nil
+}
+
+// fetchClosingIssues returns the issues that the merge request closed when
+// it was merged.
+func (g GitLab) fetchClosingIssues(owner, repo string, iid int) ([]provider.Issue, error) {
+	var issues []struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+
+	err := g.get(projectPath(owner, repo, "/merge_requests/"+strconv.Itoa(iid)+"/closes_issues"), nil, &issues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issues closed by merge request !%d: %w", iid, err)
+	}
+
+	var closingIssues []provider.Issue
+	for _, issue := range issues {
+		closingIssues = append(closingIssues, provider.Issue{
+			Number: issue.IID,
+			Url:    issue.WebURL,
+		})
+	}
+
+	return closingIssues, nil
+}
+
+func (g GitLab) FetchPullRequest(owner, repo string, pullRequestNumber int) (provider.PullRequest, error) {
+	var mr mergeRequest
+	err := g.get(projectPath(owner, repo, "/merge_requests/"+strconv.Itoa(pullRequestNumber)), nil, &mr)
+	if err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	return provider.PullRequest{
+		ID:     strconv.Itoa(mr.ID),
+		Number: mr.IID,
+		Title:  mr.Title,
+		Body:   mr.Description,
+		Author: mr.Author.Username,
+		Labels: mr.Labels,
+		Merged: mr.State == "merged",
+		Url:    mr.WebURL,
+	}, nil
+}
+
+// ResolveRevision returns the SHA of the commit that the tag, branch or commit
+// SHA points to.
+func (g GitLab) ResolveRevision(owner, repo, revision string) (string, error) {
+	var resolved commit
+	err := g.get(projectPath(owner, repo, "/repository/commits/"+url.PathEscape(revision)), nil, &resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve revision %s: %w", revision, err)
+	}
+
+	return resolved.ID, nil
+}
+
+type commit struct {
+	ID            string    `json:"id"`
+	Message       string    `json:"message"`
+	CommittedDate time.Time `json:"committed_date"`
+}
+
+// FetchCommit returns the commit with the date it was committed.
+func (g GitLab) FetchCommit(owner, repo, commitSHA string) (provider.Commit, error) {
+	var fetched commit
+	err := g.get(projectPath(owner, repo, "/repository/commits/"+commitSHA), nil, &fetched)
+	if err != nil {
+		return provider.Commit{}, fmt.Errorf("failed to fetch commit %s: %w", commitSHA, err)
+	}
+
+	return provider.Commit{
+		SHA:           fetched.ID,
+		CommittedDate: fetched.CommittedDate,
+	}, nil
+}
+
+// HasMergedPullRequestBefore looks for a merge request by the author that was
+// merged by the time the commit was made.
+func (g GitLab) HasMergedPullRequestBefore(owner, repo, author string, before provider.Commit) (bool, error) {
+
+	// Merge requests can only be filtered by when they were created, so the
+	// merge requests created before the commit are checked for when they
+	// were merged
+	for page := 1; ; page++ {
+		var mergeRequests []struct {
+			MergedAt *time.Time `json:"merged_at"`
+		}
+
+		err := g.get(projectPath(owner, repo, "/merge_requests"), url.Values{
+			"state":           {"merged"},
+			"author_username": {author},
+			"created_before":  {before.CommittedDate.UTC().Format(time.RFC3339)},
+			"page":            {strconv.Itoa(page)},
+		}, &mergeRequests)
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch merge requests of %s: %w", author, err)
+		}
+
+		for _, mr := range mergeRequests {
+			if mr.MergedAt != nil && !mr.MergedAt.After(before.CommittedDate) {
+				return true, nil
+			}
+		}
+
+		if len(mergeRequests) < perPage {
+			return false, nil
+		}
+	}
+}
+
+// walkCommits calls walk with each commit in the history of the branch,
+// starting from the latest commit, until walk returns false.
+func (g GitLab) walkCommits(owner, repo, branch string, walk func(c commit) (bool, error)) error {
+	for page := 1; ; page++ {
+		var commits []commit
+
+		err := g.get(projectPath(owner, repo, "/repository/commits"), url.Values{
+			"ref_name": {branch},
+			"page":     {strconv.Itoa(page)},
+		}, &commits)
+		if err != nil {
+			return fmt.Errorf("failed to fetch commits from gitlab: %w", err)
+		}
+
+		for _, commit := range commits {
+			next, err := walk(commit)
+			if err != nil {
+				return err
+			}
+
+			if !next {
+				return nil
+			}
+		}
+
+		if len(commits) < perPage {
+			return nil
+		}
+	}
+}
+
+func projectPath(owner, repo, path string) string {
+	return "/projects/" + url.PathEscape(owner+"/"+repo) + path
+}
+
+func (g GitLab) get(path string, query url.Values, result interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	req, err := http.NewRequest(http.MethodGet, g.baseURL+"/api/v4"+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return provider.AuthenticationFailed{Provider: "gitlab", Status: resp.Status}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,215 @@
+package gitlab_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clarafu/release-me/gitlab"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestGitLab(t *testing.T) {
+	suite.Run(t, &GitLabSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type GitLabSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	server *httptest.Server
+	client gitlab.GitLab
+}
+
+func (s *GitLabSuite) SetupTest() {
+	mux := http.NewServeMux()
+
+	respond := func(path string, body interface{}) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("PRIVATE-TOKEN") != "some-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(body)
+		})
+	}
+
+	respond("/api/v4/projects/group/subgroup/project/releases", []interface{}{
+		map[string]interface{}{"tag_name": "v1.1.0", "commit": map[string]string{"id": "sha-2"}},
+		map[string]interface{}{"tag_name": "v1.0.0", "commit": map[string]string{"id": "sha-4"}},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/tags", []interface{}{
+		map[string]interface{}{"name": "v1.1.0", "commit": map[string]string{"id": "sha-2"}},
+		map[string]interface{}{"name": "v1.0.1", "commit": map[string]string{"id": "sha-3"}},
+		map[string]interface{}{"name": "v1.0.0", "commit": map[string]string{"id": "sha-4"}},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits", []interface{}{
+		map[string]string{"id": "sha-1", "message": "Fix a bug\n\nCo-authored-by: Carol <carol@example.com>"},
+		map[string]string{"id": "sha-2"},
+		map[string]string{"id": "sha-3"},
+		map[string]string{"id": "sha-4"},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits/sha-1/merge_requests", []interface{}{
+		map[string]interface{}{
+			"id": 101, "iid": 3, "title": "Fix a bug", "description": "## Release Note\nfixed", "state": "merged",
+			"labels": []string{"bug"}, "web_url": "https://gitlab.example.com/mr/3", "author": map[string]string{"username": "alice"},
+		},
+		map[string]interface{}{"id": 105, "iid": 7, "title": "Still open", "state": "opened"},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits/sha-2/merge_requests", []interface{}{
+		map[string]interface{}{
+			"id": 102, "iid": 2, "title": "Bump dependency", "state": "merged",
+			"labels": []string{"misc"}, "author": map[string]string{"username": "renovate"},
+		},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits/sha-3/merge_requests", []interface{}{
+		map[string]interface{}{
+			"id": 103, "iid": 1, "title": "Add a feature", "state": "merged",
+			"labels": []string{"enhancement"}, "author": map[string]string{"username": "bob"},
+		},
+	})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits/v1.0.0", map[string]string{"id": "sha-4"})
+	respond("/api/v4/projects/group/subgroup/project/repository/commits/sha-4", map[string]string{"id": "sha-4", "committed_date": "2020-06-01T00:00:00Z"})
+	mux.HandleFunc("/api/v4/projects/group/subgroup/project/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		mergeRequests := []interface{}{}
+		switch r.URL.Query().Get("author_username") {
+		case "alice":
+			mergeRequests = append(mergeRequests, map[string]interface{}{"iid": 1, "merged_at": "2020-05-01T00:00:00Z"})
+		case "bob":
+			// Created before the commit but only merged after it
+			mergeRequests = append(mergeRequests, map[string]interface{}{"iid": 2, "merged_at": "2020-07-01T00:00:00Z"})
+		}
+		json.NewEncoder(w).Encode(mergeRequests)
+	})
+	respond("/api/v4/projects/group/subgroup/project/merge_requests/3/closes_issues", []interface{}{
+		map[string]interface{}{"iid": 8, "web_url": "https://gitlab.example.com/issues/8"},
+	})
+	respond("/api/v4/projects/group/subgroup/project/merge_requests/1/closes_issues", []interface{}{})
+	respond("/api/v4/projects/group/subgroup/project/merge_requests/3", map[string]interface{}{
+		"id": 101, "iid": 3, "labels": []string{"bug", "area/web"},
+		"title": "Fix the login page", "description": "## Release Note\n\nFixes logging in", "state": "opened",
+		"web_url": "https://gitlab.example.com/merge_requests/3", "author": map[string]interface{}{"username": "alice"},
+	})
+
+	s.server = httptest.NewServer(mux)
+	s.client = gitlab.New(s.server.URL+"/", "some-token")
+}
+
+func (s *GitLabSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *GitLabSuite) TestImplementsProvider() {
+	var _ provider.Provider = s.client
+}
+
+func (s *GitLabSuite) TestFetchCommitsFromReleases() {
+	releaseSHAs, err := s.client.FetchCommitsFromReleases("group/subgroup", "project")
+	s.NoError(err)
+	s.Equal(map[string]string{"sha-2": "v1.1.0", "sha-4": "v1.0.0"}, releaseSHAs)
+}
+
+func (s *GitLabSuite) TestFetchCommitsFromTags() {
+	tagSHAs, err := s.client.FetchCommitsFromTags("group/subgroup", "project")
+	s.NoError(err)
+	s.Equal(map[string]string{"sha-2": "v1.1.0", "sha-3": "v1.0.1", "sha-4": "v1.0.0"}, tagSHAs)
+}
+
+func (s *GitLabSuite) TestFetchLatestReleaseCommitFromBranch() {
+	releaseSHAs := map[string]string{"sha-2": "v1.0.1", "sha-4": "v1.0.0"}
+
+	commit, err := s.client.FetchLatestReleaseCommitFromBranch("group/subgroup", "project", "main", "1.1.0", releaseSHAs)
+	s.NoError(err)
+	s.Equal("sha-4", commit)
+
+	commit, err = s.client.FetchLatestReleaseCommitFromBranch("group/subgroup", "project", "main", "1.0.2", releaseSHAs)
+	s.NoError(err)
+	s.Equal("sha-2", commit)
+}
+
+func (s *GitLabSuite) TestFetchPullRequestsAfterCommit() {
+	pullRequests, err := s.client.FetchPullRequestsAfterCommit("group/subgroup", "project", "main", "sha-4", "", []string{"renovate"})
+	s.NoError(err)
+	s.Equal([]provider.PullRequest{
+		{
+			ID:     "101",
+			Number: 3,
+			Title:  "Fix a bug",
+			Body:   "## Release Note\nfixed",
+			Author: "alice",
+			Labels: []string{"bug"},
+			Merged: true,
+			Url:    "https://gitlab.example.com/mr/3",
+			CoAuthors: []provider.CoAuthor{
+				{Name: "Carol", Email: "carol@example.com"},
+			},
+			ClosingIssues: []provider.Issue{
+				{Number: 8, Url: "https://gitlab.example.com/issues/8"},
+			},
+		},
+		{
+			ID:     "103",
+			Number: 1,
+			Title:  "Add a feature",
+			Author: "bob",
+			Labels: []string{"enhancement"},
+			Merged: true,
+		},
+	}, pullRequests)
+}
+
+func (s *GitLabSuite) TestFetchPullRequest() {
+	pullRequest, err := s.client.FetchPullRequest("group/subgroup", "project", 3)
+	s.NoError(err)
+	s.Equal(provider.PullRequest{
+		ID:     "101",
+		Number: 3,
+		Title:  "Fix the login page",
+		Body:   "## Release Note\n\nFixes logging in",
+		Author: "alice",
+		Labels: []string{"bug", "area/web"},
+		Url:    "https://gitlab.example.com/merge_requests/3",
+	}, pullRequest)
+}
+
+func (s *GitLabSuite) TestResolveRevision() {
+	sha, err := s.client.ResolveRevision("group/subgroup", "project", "v1.0.0")
+	s.NoError(err)
+	s.Equal("sha-4", sha)
+}
+
+func (s *GitLabSuite) TestFetchCommit() {
+	commit, err := s.client.FetchCommit("group/subgroup", "project", "sha-4")
+	s.NoError(err)
+	s.Equal(provider.Commit{SHA: "sha-4", CommittedDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)}, commit)
+}
+
+func (s *GitLabSuite) TestHasMergedPullRequestBefore() {
+	before := provider.Commit{SHA: "sha-4", CommittedDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)}
+
+	contributed, err := s.client.HasMergedPullRequestBefore("group/subgroup", "project", "alice", before)
+	s.NoError(err)
+	s.True(contributed)
+
+	contributed, err = s.client.HasMergedPullRequestBefore("group/subgroup", "project", "bob", before)
+	s.NoError(err)
+	s.False(contributed)
+
+	contributed, err = s.client.HasMergedPullRequestBefore("group/subgroup", "project", "carol", before)
+	s.NoError(err)
+	s.False(contributed)
+}
+
+func (s *GitLabSuite) TestFailsWithoutAuthentication() {
+	_, err := gitlab.New(s.server.URL, "wrong-token").FetchCommitsFromReleases("group/subgroup", "project")
+
+	var authErr provider.AuthenticationFailed
+	s.True(errors.As(err, &authErr))
+}
@@ -0,0 +1,410 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+)
+
+// Repository reads releases and pull requests from the history of a local
+// clone, without using the GitHub API. Pull requests are discovered through
+// the merge commits and squashed commits that GitHub creates when merging
+// them.
+//
+// Commits only contain the number and title of a pull request. If a GitHub
+// client is given, the rest of the pull request, such as its labels and
+// body, is fetched from GitHub.
+type Repository struct {
+	path   string
+	github *github.GitHub
+}
+
+func New(path string, enrich *github.GitHub) Repository {
+	return Repository{
+		path:   path,
+		github: enrich,
+	}
+}
+
+// FetchCommitsFromReleases returns the commit of every tag in the repository,
+// since releases only exist on GitHub.
+func (r Repository) FetchCommitsFromReleases(owner, repo string) (map[string]string, error) {
+	return r.FetchCommitsFromTags(owner, repo)
+}
+
+// FetchCommitsFromTags returns the commit of every tag in the repository.
+// Annotated tags are dereferenced to the commit they point to.
+func (r Repository) FetchCommitsFromTags(owner, repo string) (map[string]string, error) {
+	out, err := r.git("for-each-ref", "--format=%(refname:short) %(objectname) %(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	releaseSHAs := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 2:
+			releaseSHAs[fields[1]] = fields[0]
+		case 3:
+			releaseSHAs[fields[2]] = fields[0]
+		}
+	}
+
+	return releaseSHAs, nil
+}
+
+func (r Repository) FetchLatestReleaseCommitFromBranch(owner, repo, branch, versionToRelease string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, lastCommit, err := r.findReleaseCommit(branch, releaseSHAs, provider.PreviousReleaseFilter(versionToRelease))
+	if err != nil {
+		return "", err
+	}
+
+	if releaseCommit == "" {
+		return lastCommit, nil
+	}
+
+	return releaseCommit, nil
+}
+
+func (r Repository) FetchMostRecentReleaseCommitFromBranch(owner, repo, branch string, releaseSHAs map[string]string) (string, error) {
+	releaseCommit, _, err := r.findReleaseCommit(branch, releaseSHAs, func(string) bool {
+		return true
+	})
+	return releaseCommit, err
+}
+
+func (r Repository) findReleaseCommit(branch string, releaseSHAs map[string]string, isPrevious func(release string) bool) (string, string, error) {
+	ref, err := r.resolveBranch(branch)
+	if err != nil {
+		return "", "", err
+	}
+
+	out, err := r.git("rev-list", ref)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var lastCommit string
+	commits := strings.Fields(out)
+	for i, commit := range commits {
+		lastCommit = commit
+
+		if previousRelease, found := releaseSHAs[commit]; found && isPrevious(previousRelease) {
+			logger.Debugf("scanned %d commits of %s to find release %s", i+1, branch, previousRelease)
+			return commit, lastCommit, nil
+		}
+	}
+
+	logger.Debugf("scanned all %d commits of %s without finding a release", len(commits), branch)
+	return "", lastCommit, nil
+}
+
+// The merge commit created by GitHub has the title of the pull request as the
+// first line of its body, and the branch of the pull request prefixed with
+// the login of the owner of its repository in its subject
+var mergeCommitRegex = regexp.MustCompile(`^Merge pull request #(\d+) from (?:([^/\s]+)/)?`)
+
+// Squashed commits are suffixed with the pull request number by default
+var squashCommitRegex = regexp.MustCompile(`^(.*) \(#(\d+)\)$`)
+
+type commit struct {
+	SHA     string
+	Author  string
+	Email   string
+	Subject string
+	Body    string
+}
+
+// parsePullRequest returns the pull request that the commit was created by
+// when it was merged. If the commit was not created by merging a pull
+// request, false is returned.
+//
+// The author of a merge commit is whoever merged the pull request, so the
+// author of the pull request is taken from the branch in its subject instead.
+// That is the owner of the repository the branch was pushed to, which is the
+// author for pull requests from forks. For branches pushed to the repository
+// itself it is the owner of the repository, so the author of the tip of the
+// merged branch is used instead. Squashed commits keep the author of the pull
+// request, but only have their name and email. Their login is taken from
+// their noreply email, falling back to their name.
+func (r Repository) parsePullRequest(owner string, c commit) (provider.PullRequest, bool, error) {
+	if groups := mergeCommitRegex.FindStringSubmatch(c.Subject); groups != nil {
+		number, err := strconv.Atoi(groups[1])
+		if err != nil {
+			return provider.PullRequest{}, false, nil
+		}
+
+		title := strings.SplitN(strings.TrimSpace(c.Body), "\n", 2)[0]
+
+		author := groups[2]
+		if author == "" {
+			author = c.Author
+		} else if owner != "" && strings.EqualFold(author, owner) {
+			author, err = r.branchAuthor(c.SHA)
+			if err != nil {
+				return provider.PullRequest{}, false, err
+			}
+		}
+
+		return provider.PullRequest{
+			ID:        c.SHA,
+			Number:    number,
+			Title:     title,
+			Author:    author,
+			Merged:    true,
+			CoAuthors: provider.ParseCoAuthors(c.Body),
+		}, true, nil
+	}
+
+	if groups := squashCommitRegex.FindStringSubmatch(c.Subject); groups != nil {
+		number, err := strconv.Atoi(groups[2])
+		if err != nil {
+			return provider.PullRequest{}, false, nil
+		}
+
+		return provider.PullRequest{
+			ID:        c.SHA,
+			Number:    number,
+			Title:     groups[1],
+			Body:      strings.TrimSpace(c.Body),
+			Author:    login(c.Author, c.Email),
+			Merged:    true,
+			CoAuthors: provider.ParseCoAuthors(c.Body),
+		}, true, nil
+	}
+
+	return provider.PullRequest{}, false, nil
+}
+
+// branchAuthor returns the login of the author of the tip of the branch merged
+// by the merge commit
+func (r Repository) branchAuthor(mergeSHA string) (string, error) {
+	out, err := r.git("log", "-1", "--format=%an%x1f%ae", mergeSHA+"^2")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the merged branch of %s: %w", mergeSHA, err)
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(out), "\x1f", 2)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("failed to read the merged branch of %s", mergeSHA)
+	}
+
+	return login(fields[0], fields[1]), nil
+}
+
+// login returns the github login of the noreply email, falling back to the
+// name
+func login(name, email string) string {
+	if login := (provider.CoAuthor{Name: name, Email: email}).Login(); login != "" {
+		return login
+	}
+
+	return name
+}
+
+func (r Repository) FetchPullRequestsAfterCommit(owner, repo, branch, startingCommitSHA, lastCommitSHA string, ignoreAuthors []string) ([]provider.PullRequest, error) {
+	head := lastCommitSHA
+	if head == "" {
+		var err error
+		head, err = r.resolveBranch(branch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revisionRange := head
+	if startingCommitSHA != "" {
+		revisionRange = startingCommitSHA + ".." + head
+	}
+
+	commits, err := r.log(revisionRange)
+	if err != nil {
+		return nil, err
+	}
+
+	filteredAuthors := make(map[string]struct{})
+	for _, username := range ignoreAuthors {
+		filteredAuthors[username] = struct{}{}
+	}
+
+	logger.Debugf("scanned %d commits of %s for pull requests", len(commits), revisionRange)
+
+	pullRequests := []provider.PullRequest{}
+	seen := make(map[int]bool)
+	for _, c := range commits {
+		pr, found, err := r.parsePullRequest(owner, c)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			continue
+		}
+
+		if seen[pr.Number] {
+			logger.Debugf("excluding pull request #%d of commit %s: duplicate of an earlier commit", pr.Number, c.SHA)
+			continue
+		}
+
+		seen[pr.Number] = true
+
+		if r.github != nil {
+			number, coAuthors := pr.Number, pr.CoAuthors
+			pr, err = r.github.FetchPullRequest(owner, repo, number)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch pull request #%d from github: %w", number, err)
+			}
+			pr.CoAuthors = coAuthors
+		}
+
+		if _, found := filteredAuthors[pr.Author]; found {
+			logger.Debugf("excluding pull request #%d: author %s is ignored", pr.Number, pr.Author)
+			continue
+		}
+
+		pullRequests = append(pullRequests, pr)
+	}
+
+	return pullRequests, nil
+}
+
+// log returns the commits in the revision range. Pull requests are merged
+// into the branch, so only the first parent of each commit is followed.
+func (r Repository) log(revisionRange string) ([]commit, error) {
+	out, err := r.git("log", "--first-parent", "--format=%H%x1f%an%x1f%ae%x1f%s%x1f%b%x1e", revisionRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var commits []commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 5)
+		if len(fields) < 5 {
+			continue
+		}
+
+		commits = append(commits, commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Subject: fields[3],
+			Body:    fields[4],
+		})
+	}
+
+	return commits, nil
+}
+
+// FetchCommit returns the commit from the clone, even when pull requests are
+// fetched from github.
+func (r Repository) FetchCommit(owner, repo, commitSHA string) (provider.Commit, error) {
+	out, err := r.git("show", "-s", "--format=%H%x1f%cI", commitSHA+"^{commit}")
+	if err != nil {
+		return provider.Commit{}, fmt.Errorf("commit %s does not exist in %s", commitSHA, r.path)
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(out), "\x1f", 2)
+	if len(fields) < 2 {
+		return provider.Commit{}, fmt.Errorf("failed to read commit %s", commitSHA)
+	}
+
+	committedDate, err := time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return provider.Commit{}, fmt.Errorf("failed to parse the date of commit %s: %w", commitSHA, err)
+	}
+
+	return provider.Commit{
+		SHA:           fields[0],
+		CommittedDate: committedDate,
+	}, nil
+}
+
+// HasMergedPullRequestBefore looks for a pull request by the author in the
+// history of the commit. When pull requests are fetched from github, github
+// is searched instead since the authors are github logins.
+func (r Repository) HasMergedPullRequestBefore(owner, repo, author string, commit provider.Commit) (bool, error) {
+	if r.github != nil {
+		return r.github.HasMergedPullRequestBefore(owner, repo, author, commit)
+	}
+
+	commits, err := r.log(commit.SHA)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range commits {
+		pr, found, err := r.parsePullRequest(owner, c)
+		if err != nil {
+			return false, err
+		}
+
+		if found && pr.Author == author {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// FetchPullRequest fetches the pull request from github, since the labels and
+// description of a pull request are not part of the history. It fails without
+// github credentials.
+func (r Repository) FetchPullRequest(owner, repo string, pullRequestNumber int) (provider.PullRequest, error) {
+	if r.github == nil {
+		return provider.PullRequest{}, fmt.Errorf("pull request #%d can only be fetched from github, which requires a github token", pullRequestNumber)
+	}
+
+	return r.github.FetchPullRequest(owner, repo, pullRequestNumber)
+}
+
+// ResolveRevision returns the SHA of the commit that the tag, branch or commit
+// SHA points to, falling back to the branch of the origin remote.
+func (r Repository) ResolveRevision(owner, repo, revision string) (string, error) {
+	ref, err := r.resolveBranch(revision)
+	if err != nil {
+		return "", fmt.Errorf("revision %s does not exist in %s", revision, r.path)
+	}
+
+	sha, err := r.git("rev-parse", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(sha), nil
+}
+
+// resolveBranch returns the ref of the branch, falling back to the branch of
+// the origin remote if it was not checked out locally.
+func (r Repository) resolveBranch(branch string) (string, error) {
+	for _, ref := range []string{branch, "origin/" + branch} {
+		_, err := r.git("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		if err == nil {
+			return ref, nil
+		}
+	}
+
+	return "", fmt.Errorf("branch %s does not exist in %s", branch, r.path)
+}
+
+func (r Repository) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.path}, args...)...)
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,259 @@
+package local_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/local"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLocal(t *testing.T) {
+	suite.Run(t, &LocalSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type LocalSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	path string
+}
+
+func (s *LocalSuite) SetupTest() {
+	var err error
+	s.path, err = ioutil.TempDir("", "releaseme")
+	s.NoError(err)
+
+	s.git("init", "-q")
+	s.git("checkout", "-q", "-b", "master")
+	s.git("commit", "-q", "--allow-empty", "-m", "initial commit")
+	s.git("tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	s.git("commit", "-q", "--allow-empty", "-m", "Add a feature (#2)", "-m", "## Release Note\n\nsomething new")
+	s.git("tag", "v1.0.1")
+	s.git("checkout", "-q", "-b", "bug")
+	s.git("commit", "-q", "--allow-empty", "-m", "fix the bug")
+	s.git("checkout", "-q", "master")
+	s.git("merge", "-q", "--no-ff", "bug", "-m", "Merge pull request #3 from someone/bug", "-m", "Fix a bug")
+	s.git("commit", "-q", "--allow-empty", "-m", "commit without a pull request")
+	s.git("commit", "-q", "--allow-empty", "-m", "Bump dependency (#4)", "--author", "dependabot <bot@example.com>")
+}
+
+func (s *LocalSuite) TearDownTest() {
+	os.RemoveAll(s.path)
+}
+
+func (s *LocalSuite) git(args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", s.path, "-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	s.NoError(err, string(out))
+	return string(out)
+}
+
+func (s *LocalSuite) revParse(rev string) string {
+	out := s.git("rev-parse", rev)
+	return out[:len(out)-1]
+}
+
+func (s *LocalSuite) TestFetchCommitsFromReleases() {
+	releaseSHAs, err := local.New(s.path, nil).FetchCommitsFromReleases("", "")
+	s.NoError(err)
+	s.Equal(map[string]string{
+		s.revParse("v1.0.0^{commit}"): "v1.0.0",
+		s.revParse("v1.0.1"):          "v1.0.1",
+	}, releaseSHAs)
+}
+
+func (s *LocalSuite) TestFetchLatestReleaseCommitFromBranch() {
+	repo := local.New(s.path, nil)
+
+	releaseSHAs, err := repo.FetchCommitsFromReleases("", "")
+	s.NoError(err)
+
+	commit, err := repo.FetchLatestReleaseCommitFromBranch("", "", "master", "1.1.0", releaseSHAs)
+	s.NoError(err)
+	s.Equal(s.revParse("v1.0.0^{commit}"), commit)
+
+	commit, err = repo.FetchLatestReleaseCommitFromBranch("", "", "master", "1.0.2", releaseSHAs)
+	s.NoError(err)
+	s.Equal(s.revParse("v1.0.1"), commit)
+}
+
+func (s *LocalSuite) TestFetchPullRequestsAfterCommit() {
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("v1.0.0^{commit}"), "", []string{"dependabot"})
+	s.NoError(err)
+	s.Equal([]provider.PullRequest{
+		{
+			ID:     s.revParse("master~2"),
+			Number: 3,
+			Title:  "Fix a bug",
+			Author: "someone",
+			Merged: true,
+		},
+		{
+			ID:     s.revParse("v1.0.1"),
+			Number: 2,
+			Title:  "Add a feature",
+			Body:   "## Release Note\n\nsomething new",
+			Author: "alice",
+			Merged: true,
+		},
+	}, pullRequests)
+}
+
+func (s *LocalSuite) TestAuthorOfMergeCommitIsOwnerOfBranch() {
+	s.git("checkout", "-q", "-b", "feature")
+	s.git("commit", "-q", "--allow-empty", "-m", "add the feature")
+	s.git("checkout", "-q", "master")
+	s.git("-c", "user.name=Carol Maintainer", "merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #5 from bob/feature", "-m", "Add a feature")
+
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("master~1"), "", []string{"bob"})
+	s.NoError(err)
+	s.Empty(pullRequests)
+
+	pullRequests, err = local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("master~1"), "", nil)
+	s.NoError(err)
+	s.Len(pullRequests, 1)
+	s.Equal("bob", pullRequests[0].Author)
+}
+
+func (s *LocalSuite) TestAuthorOfMergeCommitFromSameRepositoryIsAuthorOfBranch() {
+	s.git("checkout", "-q", "-b", "feature")
+	s.git("commit", "-q", "--allow-empty", "-m", "add the feature", "--author", "Bob Smith <123+bob@users.noreply.github.com>")
+	s.git("checkout", "-q", "-b", "other", "master")
+	s.git("commit", "-q", "--allow-empty", "-m", "add another feature", "--author", "Carol Smith <carol@example.com>")
+	s.git("checkout", "-q", "master")
+	s.git("merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #5 from concourse/feature", "-m", "Add a feature")
+	s.git("merge", "-q", "--no-ff", "other", "-m", "Merge pull request #6 from Concourse/other", "-m", "Add another feature")
+
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("concourse", "concourse", "master", s.revParse("master~2"), "", nil)
+	s.NoError(err)
+	s.Len(pullRequests, 2)
+	s.Equal("Carol Smith", pullRequests[0].Author)
+	s.Equal("bob", pullRequests[1].Author)
+
+	contributed, err := local.New(s.path, nil).HasMergedPullRequestBefore("concourse", "concourse", "bob", provider.Commit{SHA: s.revParse("master")})
+	s.NoError(err)
+	s.True(contributed)
+}
+
+func (s *LocalSuite) TestAuthorOfSquashedCommitIsLoginOfNoreplyEmail() {
+	s.git("commit", "-q", "--allow-empty", "-m", "Add a feature (#5)", "--author", "Bob Smith <123+bob@users.noreply.github.com>")
+	s.git("commit", "-q", "--allow-empty", "-m", "Add another feature (#6)", "--author", "Carol Smith <carol@example.com>")
+
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("master~2"), "", nil)
+	s.NoError(err)
+	s.Len(pullRequests, 2)
+	s.Equal("Carol Smith", pullRequests[0].Author)
+	s.Equal("bob", pullRequests[1].Author)
+}
+
+func (s *LocalSuite) TestFetchPullRequestsUpToLastCommit() {
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("v1.0.0^{commit}"), s.revParse("v1.0.1"), nil)
+	s.NoError(err)
+	s.Len(pullRequests, 1)
+	s.Equal(2, pullRequests[0].Number)
+}
+
+func (s *LocalSuite) TestResolveRevision() {
+	repo := local.New(s.path, nil)
+
+	sha, err := repo.ResolveRevision("", "", "v1.0.0")
+	s.NoError(err)
+	s.Equal(s.revParse("v1.0.0^{commit}"), sha)
+
+	sha, err = repo.ResolveRevision("", "", "bug")
+	s.NoError(err)
+	s.Equal(s.revParse("bug"), sha)
+
+	_, err = repo.ResolveRevision("", "", "missing")
+	s.Error(err)
+}
+
+func (s *LocalSuite) TestFetchPullRequestRequiresGitHub() {
+	_, err := local.New(s.path, nil).FetchPullRequest("clarafu", "release-me", 1)
+	s.EqualError(err, "pull request #1 can only be fetched from github, which requires a github token")
+}
+
+func (s *LocalSuite) TestFetchPullRequestsWithCoAuthors() {
+	s.git("commit", "-q", "--allow-empty", "-m", "Pair on a feature (#5)", "-m", "Co-authored-by: Bob <1+bob@users.noreply.github.com>")
+
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("master~1"), "", nil)
+	s.NoError(err)
+	s.Len(pullRequests, 1)
+	s.Equal([]provider.CoAuthor{{Name: "Bob", Email: "1+bob@users.noreply.github.com"}}, pullRequests[0].CoAuthors)
+}
+
+func (s *LocalSuite) TestFetchCommit() {
+	commit, err := local.New(s.path, nil).FetchCommit("", "", "v1.0.0")
+	s.NoError(err)
+	s.Equal(s.revParse("v1.0.0^{commit}"), commit.SHA)
+	s.False(commit.CommittedDate.IsZero())
+
+	_, err = local.New(s.path, nil).FetchCommit("", "", "missing")
+	s.Error(err)
+}
+
+func (s *LocalSuite) TestHasMergedPullRequestBefore() {
+	repo := local.New(s.path, nil)
+
+	contributed, err := repo.HasMergedPullRequestBefore("", "", "alice", provider.Commit{SHA: s.revParse("v1.0.1")})
+	s.NoError(err)
+	s.True(contributed)
+
+	contributed, err = repo.HasMergedPullRequestBefore("", "", "alice", provider.Commit{SHA: s.revParse("v1.0.0^{commit}")})
+	s.NoError(err)
+	s.False(contributed)
+
+	contributed, err = repo.HasMergedPullRequestBefore("", "", "someone", provider.Commit{SHA: s.revParse("master~1")})
+	s.NoError(err)
+	s.True(contributed)
+
+	contributed, err = repo.HasMergedPullRequestBefore("", "", "dependabot", provider.Commit{SHA: s.revParse("v1.0.1")})
+	s.NoError(err)
+	s.False(contributed)
+}
+
+// sectionsTemplate records the sections it is rendered with
+type sectionsTemplate struct {
+	sections []generate.Section
+}
+
+func (t *sectionsTemplate) Render(release generate.Release, sections []generate.Section) error {
+	t.sections = sections
+	return nil
+}
+
+func (s *LocalSuite) TestGenerateWithoutGitHub() {
+	pullRequests, err := local.New(s.path, nil).FetchPullRequestsAfterCommit("", "", "master", s.revParse("v1.0.0^{commit}"), "", []string{"dependabot"})
+	s.NoError(err)
+
+	err = generate.New(new(sectionsTemplate), config.Default).Generate(generate.Release{}, pullRequests)
+	s.True(errors.As(err, &generate.PullRequestsNotLabelled{}))
+
+	cfg := config.Config{
+		Sections: []config.Section{
+			{Title: "Features", Labels: []string{"enhancement"}},
+			{Title: "Changes", Fallback: true},
+		},
+	}
+
+	template := new(sectionsTemplate)
+	err = generate.New(template, cfg).Generate(generate.Release{}, pullRequests)
+	s.NoError(err)
+	s.Equal([]generate.Section{
+		{Title: "Features"},
+		{Title: "Changes", PRs: []generate.PullRequest{
+			{Title: "Add a feature", Number: 2, Author: "alice", ReleaseNote: "something new"},
+			{Title: "Fix a bug", Number: 3, Author: "someone"},
+		}},
+	}, template.sections)
+}
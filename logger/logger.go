@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name, one of debug, info, warn
+// or error.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be one of debug, info, warn or error", name)
+}
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Logger writes messages at or above its level. In the text format each
+// message is written as "level: message", while in the json format each
+// message is written as a JSON object with the time, level and message so
+// that it can be searched by log aggregators.
+type Logger struct {
+	w      io.Writer
+	level  Level
+	format string
+	now    func() time.Time
+
+	mu sync.Mutex
+}
+
+func New(w io.Writer, level Level, format string) (*Logger, error) {
+	switch format {
+	case FormatText, FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be text or json", format)
+	}
+
+	return &Logger{
+		w:      w,
+		level:  level,
+		format: format,
+		now:    time.Now,
+	}, nil
+}
+
+type entry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"msg"`
+}
+
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		json.NewEncoder(l.w).Encode(entry{
+			Time:    l.now().UTC(),
+			Level:   level.String(),
+			Message: message,
+		})
+		return
+	}
+
+	fmt.Fprintf(l.w, "%s: %s\n", level, message)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) { l.log(LevelDebug, format, args...) }
+func (l *Logger) Infof(format string, args ...interface{})  { l.log(LevelInfo, format, args...) }
+func (l *Logger) Warnf(format string, args ...interface{})  { l.log(LevelWarn, format, args...) }
+func (l *Logger) Errorf(format string, args ...interface{}) { l.log(LevelError, format, args...) }
+
+// Default is used by the package level functions. It writes messages at info
+// level and above to stderr, so that stdout only ever contains the output of
+// a command.
+var Default, _ = New(os.Stderr, LevelInfo, FormatText)
+
+func Debugf(format string, args ...interface{}) { Default.Debugf(format, args...) }
+func Infof(format string, args ...interface{})  { Default.Infof(format, args...) }
+func Warnf(format string, args ...interface{})  { Default.Warnf(format, args...) }
+func Errorf(format string, args ...interface{}) { Default.Errorf(format, args...) }
@@ -0,0 +1,67 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLogger(t *testing.T) {
+	suite.Run(t, &LoggerSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type LoggerSuite struct {
+	suite.Suite
+	*require.Assertions
+}
+
+func (s *LoggerSuite) TestText() {
+	buf := new(bytes.Buffer)
+	l, err := logger.New(buf, logger.LevelInfo, logger.FormatText)
+	s.NoError(err)
+
+	l.Debugf("scanned %d commits", 3)
+	l.Infof("published release %s", "v1.0.0")
+	l.Warnf("could not find a commit")
+	l.Errorf("failed to fetch pull requests: %s", "boom")
+
+	s.Equal(`info: published release v1.0.0
+warn: could not find a commit
+error: failed to fetch pull requests: boom
+`, buf.String())
+}
+
+func (s *LoggerSuite) TestJSON() {
+	buf := new(bytes.Buffer)
+	l, err := logger.New(buf, logger.LevelWarn, logger.FormatJSON)
+	s.NoError(err)
+
+	l.Infof("published release %s", "v1.0.0")
+	l.Warnf("pull request #%d has more than %d labels", 7, 10)
+
+	var entry map[string]string
+	s.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	s.Equal("warn", entry["level"])
+	s.Equal("pull request #7 has more than 10 labels", entry["msg"])
+	s.NotEmpty(entry["time"])
+}
+
+func (s *LoggerSuite) TestInvalidFormat() {
+	_, err := logger.New(new(bytes.Buffer), logger.LevelInfo, "yaml")
+	s.Error(err)
+}
+
+func (s *LoggerSuite) TestParseLevel() {
+	level, err := logger.ParseLevel("WARN")
+	s.NoError(err)
+	s.Equal(logger.LevelWarn, level)
+
+	_, err = logger.ParseLevel("trace")
+	s.Error(err)
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"os"
+
 	"github.com/clarafu/release-me/cmd"
+	"github.com/clarafu/release-me/logger"
 )
 
 // Grab all the PRs created after commit of last tag
@@ -9,5 +12,9 @@ import (
 // Output the result
 
 func main() {
-	cmd.Execute()
+	err := cmd.Execute()
+	if err != nil {
+		logger.Errorf("%s", err)
+		os.Exit(cmd.ExitCode(err))
+	}
 }
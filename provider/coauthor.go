@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CoAuthor is a person credited with a Co-authored-by trailer in the message
+// of a commit of a pull request.
+type CoAuthor struct {
+	Name  string
+	Email string
+}
+
+// The noreply email of a GitHub user is either login@users.noreply.github.com
+// or id+login@users.noreply.github.com
+var noreplyEmailRegex = regexp.MustCompile(`^(?:\d+\+)?([^@+]+)@users\.noreply\.github\.com$`)
+
+// Login returns the GitHub login of the co-author if it can be derived from
+// their email, or an empty string otherwise.
+func (c CoAuthor) Login() string {
+	if groups := noreplyEmailRegex.FindStringSubmatch(strings.ToLower(c.Email)); groups != nil {
+		return groups[1]
+	}
+	return ""
+}
+
+var coAuthorRegex = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// ParseCoAuthors returns the co-authors credited in the trailers of a commit
+// message.
+func ParseCoAuthors(message string) []CoAuthor {
+	var coAuthors []CoAuthor
+	for _, groups := range coAuthorRegex.FindAllStringSubmatch(message, -1) {
+		coAuthors = append(coAuthors, CoAuthor{
+			Name:  groups[1],
+			Email: groups[2],
+		})
+	}
+	return coAuthors
+}
+
+// AddCoAuthors credits the co-authors on the pull request, skipping anyone
+// that is already credited.
+func (pr *PullRequest) AddCoAuthors(coAuthors ...CoAuthor) {
+	for _, coAuthor := range coAuthors {
+		credited := false
+		for _, existing := range pr.CoAuthors {
+			if strings.EqualFold(existing.Email, coAuthor.Email) {
+				credited = true
+				break
+			}
+		}
+
+		if !credited {
+			pr.CoAuthors = append(pr.CoAuthors, coAuthor)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoAuthors(t *testing.T) {
+	coAuthors := provider.ParseCoAuthors(`Add a feature (#12)
+
+Some description
+
+Co-authored-by: Alice Smith <12345+alice@users.noreply.github.com>
+co-authored-by: Bob <bob@example.com>
+Signed-off-by: Carol <carol@example.com>`)
+
+	require.Equal(t, []provider.CoAuthor{
+		{Name: "Alice Smith", Email: "12345+alice@users.noreply.github.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	}, coAuthors)
+
+	require.Equal(t, "alice", coAuthors[0].Login())
+	require.Equal(t, "", coAuthors[1].Login())
+	require.Equal(t, "dave", provider.CoAuthor{Email: "dave@users.noreply.github.com"}.Login())
+}
@@ -0,0 +1,24 @@
+package provider
+
+import "fmt"
+
+// AuthenticationFailed is returned when the provider rejects the credentials
+// it was given, e.g. because the token is invalid or has expired.
+type AuthenticationFailed struct {
+	Provider string
+	Status   string
+}
+
+func (e AuthenticationFailed) Error() string {
+	return fmt.Sprintf("%s rejected the credentials: %s", e.Provider, e.Status)
+}
+
+// PreviousReleaseNotFound is returned when the history of the branch does not
+// contain a previous release to start the release note from.
+type PreviousReleaseNotFound struct {
+	Branch string
+}
+
+func (e PreviousReleaseNotFound) Error() string {
+	return fmt.Sprintf("could not find a previous release in the history of %s", e.Branch)
+}
@@ -0,0 +1,87 @@
+package provider
+
+import "time"
+
+// Provider is a host of git repositories, such as GitHub or GitLab, that the
+// releases of a repository and the pull requests merged between them are read
+// from.
+type Provider interface {
+	// FetchCommitsFromReleases returns the name of each release keyed by the
+	// SHA of the commit it was released from.
+	FetchCommitsFromReleases(owner, repo string) (map[string]string, error)
+
+	// FetchCommitsFromTags returns the name of each tag keyed by the SHA of
+	// the commit it points to, including tags without a release.
+	FetchCommitsFromTags(owner, repo string) (map[string]string, error)
+
+	// FetchLatestReleaseCommitFromBranch walks the history of the branch and
+	// returns the commit SHA of the latest release that the version to
+	// release should start from, as decided by IsPreviousRelease. If the
+	// branch contains no such release, the oldest commit of the branch is
+	// returned instead.
+	FetchLatestReleaseCommitFromBranch(owner, repo, branch, versionToRelease string, releaseSHAs map[string]string) (string, error)
+
+	// FetchMostRecentReleaseCommitFromBranch returns the commit SHA of the
+	// most recent release in the history of the branch, or an empty string if
+	// there is none.
+	FetchMostRecentReleaseCommitFromBranch(owner, repo, branch string, releaseSHAs map[string]string) (string, error)
+
+	// FetchPullRequestsAfterCommit returns the pull requests merged into the
+	// branch after the starting commit, up to and including the last commit.
+	FetchPullRequestsAfterCommit(owner, repo, branch, startingCommitSHA, lastCommitSHA string, ignoreAuthors []string) ([]PullRequest, error)
+
+	// FetchPullRequest returns the pull request with the number, including
+	// its description and labels, whether or not it has been merged.
+	FetchPullRequest(owner, repo string, pullRequestNumber int) (PullRequest, error)
+
+	// ResolveRevision returns the SHA of the commit that a tag, branch or
+	// commit SHA points to.
+	ResolveRevision(owner, repo, revision string) (string, error)
+
+	// FetchCommit returns the commit with the SHA.
+	FetchCommit(owner, repo, commitSHA string) (Commit, error)
+
+	// HasMergedPullRequestBefore returns true if the author had a pull
+	// request merged into the repository by the time the commit was made,
+	// including the pull request of the commit itself. The commit is fetched
+	// once by the caller, since it is the same for every author.
+	HasMergedPullRequestBefore(owner, repo, author string, commit Commit) (bool, error)
+}
+
+// Commit is a commit of a repository.
+type Commit struct {
+	SHA           string
+	CommittedDate time.Time
+}
+
+// PullRequest is a change merged into a repository, called a pull request on
+// GitHub and a merge request on GitLab.
+type PullRequest struct {
+	ID     string
+	Number int
+	Title  string
+	Body   string
+	Author string
+	Labels []string
+	Merged bool
+	Url    string
+
+	CoAuthors []CoAuthor
+
+	// ClosingIssues are the issues that merging the pull request closed
+	ClosingIssues []Issue
+}
+
+type Issue struct {
+	Number int
+	Url    string
+}
+
+func (pr PullRequest) HasLabel(label string) bool {
+	for _, lbl := range pr.Labels {
+		if lbl == label {
+			return true
+		}
+	}
+	return false
+}
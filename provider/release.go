@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/version"
+)
+
+// IsPreviousRelease returns true if the release can be used as the start of
+// the release note for the version to release.
+//
+// Major and minor releases skip patch releases, so that the release note
+// contains every change since the last major or minor release. Final releases
+// skip prereleases, so that the release note of 7.0.0 is not limited to the
+// changes since 7.0.0-rc.1. Releases that are not semantic versions are
+// never skipped.
+func IsPreviousRelease(versionToRelease, release string) bool {
+	return skipReason(versionToRelease, release) == ""
+}
+
+// PreviousReleaseFilter returns a function that is true for the releases that
+// can be used as the start of the release note for the version to release,
+// explaining why every other release is skipped.
+func PreviousReleaseFilter(versionToRelease string) func(release string) bool {
+	return func(release string) bool {
+		if reason := skipReason(versionToRelease, release); reason != "" {
+			logger.Debugf("skipping release %s: %s", release, reason)
+			return false
+		}
+		return true
+	}
+}
+
+// skipReason returns why the release cannot be used as the start of the
+// release note for the version to release, or an empty string if it can.
+func skipReason(versionToRelease, release string) string {
+	toRelease, err := version.Parse(versionToRelease)
+	if err != nil {
+		// Without knowing the version to release, only skip patch releases
+		// like major or minor releases do
+		toRelease = version.Version{}
+	}
+
+	previous, err := version.Parse(release)
+	if err != nil {
+		return ""
+	}
+
+	if !toRelease.IsPrerelease() && previous.IsPrerelease() {
+		return fmt.Sprintf("prerelease, but %s is not a prerelease", versionToRelease)
+	}
+
+	if !toRelease.IsPatch() && previous.IsPatch() {
+		return fmt.Sprintf("patch release, but %s is not a patch release", versionToRelease)
+	}
+
+	return ""
+}
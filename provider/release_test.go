@@ -0,0 +1,27 @@
+package provider_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/clarafu/release-me/logger"
+	"github.com/clarafu/release-me/provider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousReleaseFilter(t *testing.T) {
+	explained := new(bytes.Buffer)
+	defaultLogger := logger.Default
+	logger.Default, _ = logger.New(explained, logger.LevelDebug, logger.FormatText)
+	defer func() { logger.Default = defaultLogger }()
+
+	isPrevious := provider.PreviousReleaseFilter("2.0.0")
+	require.True(t, isPrevious("1.1.0"))
+	require.False(t, isPrevious("1.1.1"))
+	require.False(t, isPrevious("2.0.0-rc.1"))
+	require.True(t, isPrevious("latest"))
+
+	require.Equal(t, `debug: skipping release 1.1.1: patch release, but 2.0.0 is not a patch release
+debug: skipping release 2.0.0-rc.1: prerelease, but 2.0.0 is not a prerelease
+`, explained.String())
+}
@@ -0,0 +1,70 @@
+package version
+
+import (
+	"strconv"
+)
+
+// Bump is the part of the version that is incremented for the next release
+type Bump int
+
+const (
+	BumpPatch Bump = iota
+	BumpMinor
+	BumpMajor
+)
+
+// Next returns the version that follows v when incremented by the bump. The
+// prefix is kept and the build metadata is dropped.
+//
+// While the major version is 0, breaking changes increment the minor version
+// and everything else increments the patch version.
+//
+// If prerelease is set, the next version is a prerelease with that
+// identifier, e.g. 1.2.0-rc.1. A prerelease of v with the same identifier is
+// continued, e.g. 1.2.0-rc.1 is followed by 1.2.0-rc.2. Otherwise a
+// prerelease is followed by its release, e.g. 1.2.0-rc.2 is followed by
+// 1.2.0, unless the bump requires a higher version.
+func (v Version) Next(bump Bump, prerelease string) Version {
+	if v.Major == 0 && bump > BumpPatch {
+		bump--
+	}
+
+	next := Version{
+		Prefix: v.Prefix,
+		Major:  v.Major,
+		Minor:  v.Minor,
+		Patch:  v.Patch,
+	}
+
+	// A prerelease already has its version incremented from the release
+	// before it, so it only needs to be incremented again if the bump
+	// requires a higher version
+	switch {
+	case bump == BumpMajor && (!v.IsPrerelease() || v.Minor != 0 || v.Patch != 0):
+		next.Major, next.Minor, next.Patch = v.Major+1, 0, 0
+	case bump == BumpMinor && (!v.IsPrerelease() || v.Patch != 0):
+		next.Minor, next.Patch = v.Minor+1, 0
+	case bump == BumpPatch && !v.IsPrerelease():
+		next.Patch++
+	}
+
+	if prerelease == "" {
+		return next
+	}
+
+	number := 1
+	if next.Compare(v.core()) == 0 && len(v.Prerelease) == 2 && v.Prerelease[0] == prerelease {
+		previousNumber, err := strconv.Atoi(v.Prerelease[1])
+		if err == nil {
+			number = previousNumber + 1
+		}
+	}
+
+	next.Prerelease = []string{prerelease, strconv.Itoa(number)}
+
+	return next
+}
+
+func (v Version) core() Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
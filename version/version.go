@@ -0,0 +1,168 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The prefix is anything before the version that does not end in a digit or
+// dot, such as the v in v1.2.3 or the release- in release-1.2.3
+var versionRegex = regexp.MustCompile(`^(|.*[^0-9.])(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// Version is a semantic version, as described by https://semver.org, that
+// is optionally prefixed like most release tags are.
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	Build      string
+}
+
+type InvalidVersion struct {
+	Version string
+}
+
+func (e InvalidVersion) Error() string {
+	return fmt.Sprintf("%q is not a semantic version", e.Version)
+}
+
+func Parse(version string) (Version, error) {
+	segments := versionRegex.FindStringSubmatch(version)
+	if segments == nil {
+		return Version{}, InvalidVersion{Version: version}
+	}
+
+	major, err := strconv.Atoi(segments[2])
+	if err != nil {
+		return Version{}, InvalidVersion{Version: version}
+	}
+
+	minor, err := strconv.Atoi(segments[3])
+	if err != nil {
+		return Version{}, InvalidVersion{Version: version}
+	}
+
+	patch, err := strconv.Atoi(segments[4])
+	if err != nil {
+		return Version{}, InvalidVersion{Version: version}
+	}
+
+	var prerelease []string
+	if segments[5] != "" {
+		prerelease = strings.Split(segments[5], ".")
+		for _, identifier := range prerelease {
+			if len(identifier) > 1 && identifier[0] == '0' && isNumeric(identifier) {
+				return Version{}, InvalidVersion{Version: version}
+			}
+		}
+	}
+
+	return Version{
+		Prefix:     segments[1],
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		Prerelease: prerelease,
+		Build:      segments[6],
+	}, nil
+}
+
+func (v Version) String() string {
+	version := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		version += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		version += "+" + v.Build
+	}
+	return version
+}
+
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// IsPatch returns true if the version only increments the patch version of
+// a previous release, e.g. 1.2.3 or 1.2.3-rc.1
+func (v Version) IsPatch() bool {
+	return v.Patch != 0
+}
+
+// Compare returns -1 if v has a lower precedence than other, 1 if it has a
+// higher precedence and 0 if both have the same precedence. The prefix and
+// the build metadata are ignored.
+func (v Version) Compare(other Version) int {
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// A prerelease has a lower precedence than its release
+	switch {
+	case !v.IsPrerelease() && !other.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !other.IsPrerelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(len(v.Prerelease), len(other.Prerelease))
+}
+
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// compareIdentifier compares prerelease identifiers. Numeric identifiers are
+// compared numerically and have a lower precedence than alphanumeric ones.
+func compareIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		aNum, _ := strconv.Atoi(a)
+		bNum, _ := strconv.Atoi(b)
+		return compareInt(aNum, bNum)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isNumeric(identifier string) bool {
+	for _, r := range identifier {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return identifier != ""
+}
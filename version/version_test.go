@@ -0,0 +1,137 @@
+package version_test
+
+import (
+	"testing"
+
+	"github.com/clarafu/release-me/version"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestVersion(t *testing.T) {
+	suite.Run(t, &VersionSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type VersionSuite struct {
+	suite.Suite
+	*require.Assertions
+}
+
+func (s *VersionSuite) TestParse() {
+	for _, t := range []struct {
+		Version string
+		Parsed  version.Version
+	}{
+		{Version: "1.2.3", Parsed: version.Version{Major: 1, Minor: 2, Patch: 3}},
+		{Version: "v7.0.0", Parsed: version.Version{Prefix: "v", Major: 7}},
+		{Version: "release-0.10.0", Parsed: version.Version{Prefix: "release-", Minor: 10}},
+		{Version: "v7.0.0-rc.1", Parsed: version.Version{Prefix: "v", Major: 7, Prerelease: []string{"rc", "1"}}},
+		{Version: "1.0.0-alpha-1.x+build.5", Parsed: version.Version{Major: 1, Prerelease: []string{"alpha-1", "x"}, Build: "build.5"}},
+		{Version: "1.0.0+20200601", Parsed: version.Version{Major: 1, Build: "20200601"}},
+	} {
+		s.Run(t.Version, func() {
+			parsed, err := version.Parse(t.Version)
+			s.NoError(err)
+			s.Equal(t.Parsed, parsed)
+			s.Equal(t.Version, parsed.String())
+		})
+	}
+}
+
+func (s *VersionSuite) TestParseInvalid() {
+	for _, v := range []string{
+		"",
+		"latest",
+		"1.2",
+		"1.2.3.4",
+		"01.2.3",
+		"1.2.3-",
+		"1.2.3-rc..1",
+		"1.2.3-rc.01",
+		"1.2.3+",
+	} {
+		s.Run(v, func() {
+			_, err := version.Parse(v)
+			s.Equal(version.InvalidVersion{Version: v}, err)
+		})
+	}
+}
+
+func (s *VersionSuite) TestCompare() {
+	// Ordered by precedence, as given by https://semver.org
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"v1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, err := version.Parse(ordered[i])
+			s.NoError(err)
+			b, err := version.Parse(ordered[j])
+			s.NoError(err)
+
+			switch {
+			case i < j:
+				s.Equal(-1, a.Compare(b), "%s < %s", a, b)
+				s.True(a.LessThan(b))
+			case i > j:
+				s.Equal(1, a.Compare(b), "%s > %s", a, b)
+			default:
+				s.Equal(0, a.Compare(b))
+			}
+		}
+	}
+}
+
+func (s *VersionSuite) TestCompareIgnoresPrefixAndBuild() {
+	a, err := version.Parse("v1.0.0+build.1")
+	s.NoError(err)
+	b, err := version.Parse("1.0.0+build.2")
+	s.NoError(err)
+	s.Equal(0, a.Compare(b))
+}
+
+func (s *VersionSuite) TestNext() {
+	for _, t := range []struct {
+		It string
+
+		Previous   string
+		Bump       version.Bump
+		Prerelease string
+
+		Next string
+	}{
+		{It: "bumps the patch", Previous: "1.2.3", Bump: version.BumpPatch, Next: "1.2.4"},
+		{It: "bumps the minor", Previous: "1.2.3", Bump: version.BumpMinor, Next: "1.3.0"},
+		{It: "bumps the major", Previous: "1.2.3", Bump: version.BumpMajor, Next: "2.0.0"},
+		{It: "keeps the prefix", Previous: "v1.2.3", Bump: version.BumpMinor, Next: "v1.3.0"},
+		{It: "drops the build metadata", Previous: "1.2.3+build.1", Bump: version.BumpPatch, Next: "1.2.4"},
+		{It: "starts from 0.0.0", Previous: "0.0.0", Bump: version.BumpMinor, Next: "0.0.1"},
+		{It: "bumps the minor for breaking changes in 0.x", Previous: "0.2.3", Bump: version.BumpMajor, Next: "0.3.0"},
+		{It: "bumps the patch for features in 0.x", Previous: "0.2.3", Bump: version.BumpMinor, Next: "0.2.4"},
+		{It: "starts a prerelease", Previous: "1.2.3", Bump: version.BumpMinor, Prerelease: "rc", Next: "1.3.0-rc.1"},
+		{It: "continues a prerelease", Previous: "1.3.0-rc.1", Bump: version.BumpMinor, Prerelease: "rc", Next: "1.3.0-rc.2"},
+		{It: "restarts a prerelease with another identifier", Previous: "1.3.0-alpha.4", Bump: version.BumpPatch, Prerelease: "rc", Next: "1.3.0-rc.1"},
+		{It: "finalizes a prerelease", Previous: "1.3.0-rc.2", Bump: version.BumpMinor, Next: "1.3.0"},
+		{It: "bumps a prerelease that does not cover the bump", Previous: "1.3.0-rc.2", Bump: version.BumpMajor, Prerelease: "rc", Next: "2.0.0-rc.1"},
+	} {
+		s.Run(t.It, func() {
+			previous, err := version.Parse(t.Previous)
+			s.NoError(err)
+			s.Equal(t.Next, previous.Next(t.Bump, t.Prerelease).String())
+		})
+	}
+}
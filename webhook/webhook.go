@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/generate"
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/logger"
+)
+
+// DefaultStatusContext is the context of the commit statuses reported by the
+// server, unless another one is configured.
+const DefaultStatusContext = "releaseme/labels"
+
+// maxDescriptionLength is the longest description GitHub accepts for a
+// commit status
+const maxDescriptionLength = 140
+
+// StatusReporter reports the status of a commit.
+type StatusReporter interface {
+	CreateCommitStatus(owner, repo, sha string, status github.CommitStatus) error
+}
+
+// Server validates the labels of the pull requests that GitHub sends
+// pull_request webhooks for, and reports the result as a status of the head
+// commit of the pull request.
+type Server struct {
+	config        config.Config
+	secret        []byte
+	statusContext string
+	statuses      StatusReporter
+}
+
+// New returns a server that verifies the webhooks are signed with the secret.
+// An empty secret disables verification, which is only meant for testing.
+func New(cfg config.Config, secret, statusContext string, statuses StatusReporter) *Server {
+	if statusContext == "" {
+		statusContext = DefaultStatusContext
+	}
+
+	return &Server{
+		config:        cfg,
+		secret:        []byte(secret),
+		statusContext: statusContext,
+		statuses:      statuses,
+	}
+}
+
+type pullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Body   string `json:"body"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"pull_request"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// validatedActions are the actions of pull_request events that can change
+// whether the labels or release note of the pull request are valid, or that
+// move the head of the pull request to a commit without a status.
+var validatedActions = map[string]bool{
+	"opened":      true,
+	"edited":      true,
+	"synchronize": true,
+	"labeled":     true,
+	"unlabeled":   true,
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	err = s.verify(body, r.Header.Get("X-Hub-Signature-256"))
+	if err != nil {
+		logger.Warnf("rejecting webhook %s: %s", r.Header.Get("X-GitHub-Delivery"), err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	if event != "pull_request" {
+		logger.Debugf("ignoring %s webhook %s", event, r.Header.Get("X-GitHub-Delivery"))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var payload pullRequestEvent
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse payload: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if !validatedActions[payload.Action] {
+		logger.Debugf("ignoring %s action of pull request #%d", payload.Action, payload.Number)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	owner, repo := payload.Repository.Owner.Login, payload.Repository.Name
+
+	var labels []string
+	for _, label := range payload.PullRequest.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	status := s.status(labels, payload.PullRequest.Body)
+	err = s.statuses.CreateCommitStatus(owner, repo, payload.PullRequest.Head.SHA, status)
+	if err != nil {
+		logger.Errorf("failed to report status of pull request #%d of %s/%s: %s", payload.Number, owner, repo, err)
+		http.Error(w, "failed to report status", http.StatusBadGateway)
+		return
+	}
+
+	logger.Infof("reported %s for pull request #%d of %s/%s", status.State, payload.Number, owner, repo)
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the signature GitHub computes over the body using the secret
+// of the webhook
+func (s *Server) verify(body []byte, signature string) error {
+	if len(s.secret) == 0 {
+		return nil
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("missing X-Hub-Signature-256 header")
+	}
+
+	actual, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return errors.New("malformed X-Hub-Signature-256 header")
+	}
+
+	if !hmac.Equal(actual, Sign(s.secret, body)) {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
+
+// Sign returns the HMAC-SHA256 of the body, as sent by GitHub in the
+// X-Hub-Signature-256 header as "sha256=" followed by its hex encoding.
+func Sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// status returns the commit status for a pull request with the labels and
+// body, using the same validation as the validate command
+func (s *Server) status(labels []string, body string) github.CommitStatus {
+	if !generate.Validate(s.config, labels) {
+		return s.failure("Must be labelled with one of: " + strings.Join(s.config.ValidLabels(), ", "))
+	}
+
+	err := generate.ValidateReleaseNote(s.config, labels, body)
+	if err != nil {
+		return s.failure(err.Error())
+	}
+
+	return github.CommitStatus{
+		State:       "success",
+		Description: "Pull request has valid labels and release note",
+		Context:     s.statusContext,
+	}
+}
+
+// failure returns a failed commit status, shortening the description to the
+// length GitHub accepts
+func (s *Server) failure(description string) github.CommitStatus {
+	if runes := []rune(description); len(runes) > maxDescriptionLength {
+		description = string(runes[:maxDescriptionLength-3]) + "..."
+	}
+
+	return github.CommitStatus{
+		State:       "failure",
+		Description: description,
+		Context:     s.statusContext,
+	}
+}
+
+// Status is a commit status recorded by Memory.
+type Status struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	SHA   string `json:"sha"`
+
+	github.CommitStatus
+}
+
+// Memory records the statuses instead of reporting them to GitHub, so that
+// the server can be tried out locally by sending it recorded payloads.
+type Memory struct {
+	mu       sync.Mutex
+	statuses []Status
+}
+
+func (m *Memory) CreateCommitStatus(owner, repo, sha string, status github.CommitStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.statuses = append(m.statuses, Status{
+		Owner:        owner,
+		Repo:         repo,
+		SHA:          sha,
+		CommitStatus: status,
+	})
+
+	return nil
+}
+
+// Statuses returns every status recorded so far, oldest first.
+func (m *Memory) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Status(nil), m.statuses...)
+}
+
+// ServeHTTP lists the recorded statuses as JSON.
+func (m *Memory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	statuses := m.Statuses()
+	if statuses == nil {
+		statuses = []Status{}
+	}
+
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,175 @@
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/clarafu/release-me/config"
+	"github.com/clarafu/release-me/github"
+	"github.com/clarafu/release-me/webhook"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const secret = "some-secret"
+
+func TestWebhook(t *testing.T) {
+	suite.Run(t, &WebhookSuite{
+		Assertions: require.New(t),
+	})
+}
+
+type WebhookSuite struct {
+	suite.Suite
+	*require.Assertions
+
+	statuses *webhook.Memory
+	server   *webhook.Server
+}
+
+func (s *WebhookSuite) SetupTest() {
+	s.statuses = &webhook.Memory{}
+	s.server = webhook.New(config.Default, secret, "", s.statuses)
+}
+
+// deliver sends the recorded payload to the server, signed with the secret
+func (s *WebhookSuite) deliver(event, payloadPath string) *httptest.ResponseRecorder {
+	payload, err := ioutil.ReadFile(payloadPath)
+	s.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(webhook.Sign([]byte(secret), payload)))
+
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func (s *WebhookSuite) TestLabeled() {
+	resp := s.deliver("pull_request", "testdata/pull_request_labeled.json")
+	s.Equal(http.StatusOK, resp.Code)
+
+	s.Equal([]webhook.Status{
+		{
+			Owner: "clarafu",
+			Repo:  "release-me",
+			SHA:   "9e2a1b7c4d5e6f708192a3b4c5d6e7f809102a3b",
+			CommitStatus: github.CommitStatus{
+				State:       "success",
+				Description: "Pull request has valid labels and release note",
+				Context:     webhook.DefaultStatusContext,
+			},
+		},
+	}, s.statuses.Statuses())
+}
+
+func (s *WebhookSuite) TestUnlabeled() {
+	resp := s.deliver("pull_request", "testdata/pull_request_unlabeled.json")
+	s.Equal(http.StatusOK, resp.Code)
+
+	statuses := s.statuses.Statuses()
+	s.Len(statuses, 1)
+	s.Equal("failure", statuses[0].State)
+	s.Equal("Must be labelled with one of: breaking, misc, bug, enhancement", statuses[0].Description)
+}
+
+func (s *WebhookSuite) TestEmptyReleaseNote() {
+	resp := s.deliver("pull_request", "testdata/pull_request_edited.json")
+	s.Equal(http.StatusOK, resp.Code)
+
+	statuses := s.statuses.Statuses()
+	s.Len(statuses, 1)
+	s.Equal("4c1d2e3f405162738495a6b7c8d9e0f112233445", statuses[0].SHA)
+	s.Equal("failure", statuses[0].State)
+	s.Equal(`rule release-note-empty failed: pull requests labelled "enhancement" need a non-empty "## Release Note" section, or "NONE" to opt out`, statuses[0].Description)
+}
+
+func (s *WebhookSuite) TestReleaseNote() {
+	resp := s.deliver("pull_request", "testdata/pull_request_opened.json")
+	s.Equal(http.StatusOK, resp.Code)
+
+	statuses := s.statuses.Statuses()
+	s.Len(statuses, 1)
+	s.Equal("success", statuses[0].State)
+}
+
+func (s *WebhookSuite) TestIgnoresOtherActionsAndEvents() {
+	resp := s.deliver("pull_request", "testdata/pull_request_closed.json")
+	s.Equal(http.StatusAccepted, resp.Code)
+
+	resp = s.deliver("ping", "testdata/ping.json")
+	s.Equal(http.StatusAccepted, resp.Code)
+
+	s.Empty(s.statuses.Statuses())
+}
+
+func (s *WebhookSuite) TestRejectsInvalidSignatures() {
+	payload, err := ioutil.ReadFile("testdata/pull_request_labeled.json")
+	s.NoError(err)
+
+	for _, signature := range []string{
+		"",
+		"sha256=not-hex",
+		"sha256=" + hex.EncodeToString(webhook.Sign([]byte("other-secret"), payload)),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		req.Header.Set("X-Hub-Signature-256", signature)
+
+		recorder := httptest.NewRecorder()
+		s.server.ServeHTTP(recorder, req)
+		s.Equal(http.StatusUnauthorized, recorder.Code)
+	}
+
+	s.Empty(s.statuses.Statuses())
+}
+
+func (s *WebhookSuite) TestWithoutSecret() {
+	s.server = webhook.New(config.Default, "", "", s.statuses)
+
+	payload, err := ioutil.ReadFile("testdata/pull_request_labeled.json")
+	s.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+
+	recorder := httptest.NewRecorder()
+	s.server.ServeHTTP(recorder, req)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Len(s.statuses.Statuses(), 1)
+}
+
+type failingStatuses struct{}
+
+func (failingStatuses) CreateCommitStatus(owner, repo, sha string, status github.CommitStatus) error {
+	return errors.New("github is down")
+}
+
+func (s *WebhookSuite) TestFailsWhenStatusCannotBeReported() {
+	s.server = webhook.New(config.Default, secret, "ci/labels", failingStatuses{})
+
+	resp := s.deliver("pull_request", "testdata/pull_request_labeled.json")
+	s.Equal(http.StatusBadGateway, resp.Code)
+}
+
+func (s *WebhookSuite) TestListsRecordedStatuses() {
+	recorder := httptest.NewRecorder()
+	s.statuses.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/statuses", nil))
+	s.JSONEq(`[]`, recorder.Body.String())
+
+	s.deliver("pull_request", "testdata/pull_request_labeled.json")
+
+	recorder = httptest.NewRecorder()
+	s.statuses.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/statuses", nil))
+
+	var statuses []webhook.Status
+	s.NoError(json.Unmarshal(recorder.Body.Bytes(), &statuses))
+	s.Equal(s.statuses.Statuses(), statuses)
+}